@@ -2,30 +2,41 @@ package bot
 
 import (
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"laverdad-bot/db"
 	googleapi "laverdad-bot/google-api"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
 	"laverdad-bot/services"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-var adminIDs = map[int64]bool{
-	115775166: true,
-	107463316: true,
-	102833932: true,
-}
-
 type AdminState struct {
 	Step      string
 	TempEvent db.Event
+	Broadcast BroadcastDraft
 }
 
-var adminStates = map[int64]*AdminState{}
+var (
+	adminStates   = map[int64]*AdminState{}
+	adminStatesMu sync.Mutex
+)
 
-func IsAdmin(userID int64) bool {
-	return adminIDs[userID]
+func getAdminState(userID int64) *AdminState {
+	adminStatesMu.Lock()
+	defer adminStatesMu.Unlock()
+	state, ok := adminStates[userID]
+	if !ok {
+		state = &AdminState{}
+		adminStates[userID] = state
+	}
+	return state
 }
 
 func HandleAdmin(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
@@ -34,26 +45,33 @@ func HandleAdmin(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
 		return
 	}
 
-	state, ok := adminStates[msg.From.ID]
-	if !ok {
-		state = &AdminState{}
-		adminStates[msg.From.ID] = state
+	state := getAdminState(msg.From.ID)
+
+	if state.Step != "" && msg.Command() == "cancel" {
+		state.Step = ""
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Действие отменено."))
+		return
 	}
 
 	switch state.Step {
 	case "":
-		switch msg.Text {
-		case "/notify_registration":
+		if perm, ok := commandPermission(msg.Command()); ok && !HasPermission(msg.From.ID, perm) {
+			bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Нет доступа к этой команде"))
+			return
+		}
+
+		switch msg.Command() {
+		case "notify_registration":
 			services.NotifyRegistrationStarted(bot)
-		case "/generate":
-			services.CreateFridayEvent()
-			services.CreateSaturdayEvent()
-			services.CreateSundayEvent()
-			bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "✅ События успешно созданы!"))
-		case "/addevent":
+			audit(msg.From.ID, "notify_registration", "", nil, nil)
+		case "broadcast":
+			startBroadcast(bot, msg, state)
+		case "generate":
+			handleGenerate(bot, msg)
+		case "addevent":
 			state.Step = "title"
 			bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Введите заголовок события:"))
-		case "/registrations":
+		case "registrations":
 			events := db.GetEvents()
 			if len(events) == 0 {
 				bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Пока нет доступных событий."))
@@ -66,9 +84,9 @@ func HandleAdmin(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
 			var rows [][]tgbotapi.InlineKeyboardButton
 			for _, e := range events {
 				row := tgbotapi.NewInlineKeyboardRow(
-					tgbotapi.NewInlineKeyboardButtonData(
+					callbackButton(
 						fmt.Sprintf("%s — %s", e.Title, e.StartsAt.Format("02.01 15:04")),
-						fmt.Sprintf("admin_ev_%d", e.ID),
+						ActionAdminEvent, e.ID,
 					),
 				)
 				rows = append(rows, row)
@@ -77,31 +95,283 @@ func HandleAdmin(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
 			msg := tgbotapi.NewMessage(msg.Chat.ID, text)
 			msg.ReplyMarkup = markup
 			bot.Send(msg)
+		case "templates":
+			handleListTemplates(bot, msg)
+		case "template":
+			handleShowTemplate(bot, msg)
+		case "template_preview":
+			handlePreviewTemplate(bot, msg)
+		case "template_set":
+			handleSetTemplate(bot, msg)
+		case "template_reset":
+			handleResetTemplate(bot, msg)
+		case "invite_code":
+			handleInviteCode(bot, msg)
+		case "register":
+			handleAdminRegister(bot, msg)
+		case "register_guest":
+			handleAdminRegisterGuest(bot, msg)
+		case "event_text":
+			handleEventText(bot, msg)
+		case "membership":
+			handleIssueMembership(bot, msg)
+		case "extend_membership":
+			handleExtendMembership(bot, msg)
+		case "memberships":
+			handleListMemberships(bot, msg)
+		case "report":
+			handlePaymentReport(bot, msg)
+		case "nick_duplicates":
+			handleNicknameDuplicates(bot, msg)
+		case "roles":
+			handleListRoles(bot, msg)
+		case "grant":
+			handleGrantRole(bot, msg)
+		case "revoke":
+			handleRevokeRole(bot, msg)
+		case "audit":
+			handleAudit(bot, msg)
+		case "audit_export":
+			handleAuditExport(bot, msg)
 		default:
-			bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Доступные команды:\n/addevent\n/registrations\n/generate\n/notify_registration"))
+			bot.Send(tgbotapi.NewMessage(msg.Chat.ID, adminHelp(msg.From.ID)))
 		}
+	case "broadcast_message":
+		handleBroadcastMessage(bot, msg, state)
+	case "broadcast_weeks":
+		handleBroadcastWeeks(bot, msg, state)
+	case "broadcast_audience", "broadcast_confirm":
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Воспользуйтесь кнопками выше или /cancel"))
 	case "title":
 		state.TempEvent.Title = msg.Text
 		state.Step = "description"
-		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Введите описание события:"))
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Введите описание события (можно с HTML-разметкой: <b>, <i>, <a href>):"))
 	case "description":
 		state.TempEvent.Description = msg.Text
 		state.Step = "location"
-		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Введите место проведения события:"))
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Введите место проведения события (можно со ссылкой <a href=\"...\">адрес</a>):"))
 	case "location":
 		state.TempEvent.Location = msg.Text
 		state.Step = "datetime"
-		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Введите дату и время события в формате 2006-01-02 15:04:"))
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Введите дату и время события по времени клуба (%s) в формате 2006-01-02 15:04:", locales.ClubLocation)))
 	case "datetime":
-		dt, err := time.Parse("2006-01-02 15:04", msg.Text)
+		dt, err := locales.ParseClubTime("2006-01-02 15:04", msg.Text)
 		if err != nil {
 			bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Неверный формат, попробуйте ещё раз:"))
 			return
 		}
 		state.TempEvent.StartsAt = dt
-		db.CreateEvent(state.TempEvent)
-		go googleapi.AddNewSheet(fmt.Sprintf("%s - %s", state.TempEvent.Title, state.TempEvent.StartsAt.Format("02.01")))
-		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "✅ Событие добавлено!"))
 		state.Step = ""
+		id, err := db.CreateEvent(state.TempEvent)
+		if err != nil {
+			log.Println("CreateEvent error:", err)
+			sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Не удалось создать событие: %v", err)))
+			return
+		}
+		state.TempEvent.ID = id
+		audit(msg.From.ID, "addevent", state.TempEvent.Title, nil, state.TempEvent)
+		sheetName := googleapi.SheetName(state.TempEvent.Title, state.TempEvent.StartsAt)
+		googleapi.Async(func() { googleapi.AddNewSheet(sheetName) })
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "✅ Событие добавлено!"))
+	}
+}
+
+// /membership <@username|ник|telegram_id> <тип> <дней> [игр]
+// Создать игры недели. Повторный /generate не создаёт дублей: уже созданные игры пропускаются
+func handleGenerate(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	created, skipped, failed := 0, 0, 0
+	for _, create := range []func() (bool, error){services.CreateFridayEvent, services.CreateSaturdayEvent, services.CreateSundayEvent} {
+		ok, err := create()
+		switch {
+		case err != nil:
+			log.Println(err)
+			failed++
+		case ok:
+			created++
+		default:
+			skipped++
+		}
+	}
+	if created > 0 {
+		audit(msg.From.ID, "generate", "week", nil, map[string]int{"created": created})
+	}
+
+	text := fmt.Sprintf("✅ Создано событий: %d, уже были: %d", created, skipped)
+	if failed > 0 {
+		text += fmt.Sprintf("\n❌ Не удалось создать: %d", failed)
+	}
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+}
+
+func handleIssueMembership(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) < 3 {
+		sendText(bot, msg.Chat.ID, "Формат: <code>/membership @username тип дней [игр]</code>\nНапример: <code>/membership @ivan monthly 30 8</code> (0 игр — без ограничений)")
+		return
+	}
+	days, err := strconv.Atoi(args[2])
+	if err != nil || days <= 0 {
+		sendText(bot, msg.Chat.ID, "Количество дней должно быть положительным числом.")
+		return
+	}
+	games := 0
+	if len(args) > 3 {
+		games, err = strconv.Atoi(args[3])
+		if err != nil || games < 0 {
+			sendText(bot, msg.Chat.ID, "Количество игр должно быть неотрицательным числом.")
+			return
+		}
+	}
+
+	user, err := db.FindUser(args[0])
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	m, err := db.CreateMembership(user.ID, args[1], days, games)
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	audit(msg.From.ID, "membership_issue", strconv.FormatInt(user.TelegramID, 10), nil, m)
+
+	sendText(bot, msg.Chat.ID, "✅ Абонемент выдан:\n"+formatMembership(user.Name, user.Nickname, m))
+	sendText(bot, user.ChatID, render.T(locales.ParseLang(user.Language), "membership.issued", m.Type, locales.ClubTime(m.EndsAt).Format("02.01.2006")))
+}
+
+// /extend_membership <@username|ник|telegram_id> <дней> [игр]
+func handleExtendMembership(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) < 2 {
+		sendText(bot, msg.Chat.ID, "Формат: <code>/extend_membership @username дней [игр]</code>")
+		return
+	}
+	days, err := strconv.Atoi(args[1])
+	if err != nil || days < 0 {
+		sendText(bot, msg.Chat.ID, "Количество дней должно быть неотрицательным числом.")
+		return
+	}
+	games := 0
+	if len(args) > 2 {
+		games, err = strconv.Atoi(args[2])
+		if err != nil || games < 0 {
+			sendText(bot, msg.Chat.ID, "Количество игр должно быть неотрицательным числом.")
+			return
+		}
+	}
+
+	user, err := db.FindUser(args[0])
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	before, _ := db.GetLatestMembership(user.ID)
+	m, err := db.ExtendMembership(user.ID, days, games)
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: у пользователя нет абонемента (%v)", err)))
+		return
+	}
+	audit(msg.From.ID, "membership_extend", strconv.FormatInt(user.TelegramID, 10), before, m)
+
+	sendText(bot, msg.Chat.ID, "✅ Абонемент продлён:\n"+formatMembership(user.Name, user.Nickname, m))
+	sendText(bot, user.ChatID, render.T(locales.ParseLang(user.Language), "membership.extended", m.Type, locales.ClubTime(m.EndsAt).Format("02.01.2006")))
+}
+
+// /event_text <id события> <ru|es|en>, а со следующей строки — описание события на этом языке
+func handleEventText(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	header, text, _ := strings.Cut(msg.CommandArguments(), "\n")
+	args := strings.Fields(header)
+	text = strings.TrimSpace(text)
+	if len(args) != 2 || text == "" {
+		sendText(bot, msg.Chat.ID, "Формат: <code>/event_text &lt;id&gt; &lt;ru|es|en&gt;</code>, а со следующей строки — текст описания.\nid события можно посмотреть в /registrations.")
+		return
+	}
+	eventID, err := strconv.Atoi(args[0])
+	if err != nil {
+		sendText(bot, msg.Chat.ID, "id события должен быть числом.")
+		return
+	}
+	lang := locales.Lang(strings.ToLower(args[1]))
+	if _, ok := locales.LangNames[lang]; !ok {
+		sendText(bot, msg.Chat.ID, "Язык должен быть одним из: ru, es, en.")
+		return
+	}
+	event, err := db.FetchEvent(int64(eventID))
+	if err != nil {
+		sendText(bot, msg.Chat.ID, "Событие не найдено.")
+		return
+	}
+
+	if err := db.SetEventDescription(eventID, lang, text); err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	audit(msg.From.ID, "event_text", strconv.Itoa(eventID), event.Descriptions[lang], text)
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("✅ Описание «%s» на языке %s сохранено.", event.Title, lang)))
+}
+
+func handleListMemberships(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	memberships := db.GetActiveMemberships()
+	if len(memberships) == 0 {
+		sendText(bot, msg.Chat.ID, "Действующих абонементов нет.")
+		return
+	}
+
+	text := "Действующие абонементы:\n\n"
+	for _, m := range memberships {
+		text += formatMembership(m.Name, m.Nickname, m.Membership) + "\n"
+	}
+	sendText(bot, msg.Chat.ID, text)
+}
+
+// /report [дней] — сколько игр оплачено абонементами, а сколько разовыми донатами
+func handlePaymentReport(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	days := 30
+	if arg := strings.TrimSpace(msg.CommandArguments()); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n <= 0 {
+			sendText(bot, msg.Chat.ID, "Формат: <code>/report [дней]</code>")
+			return
+		}
+		days = n
+	}
+
+	stats, err := db.GetPaymentStats(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+
+	text := fmt.Sprintf(`Отчёт за последние %d дн.:
+
+🎫 По абонементам: %d
+💶 Разовые донаты: %d (≈ %d€)`, days, stats.ByMembership, stats.ByDonation, stats.ByDonation*db.DonationEUR)
+	sendText(bot, msg.Chat.ID, text)
+}
+
+// Отчёт о пользователях с одинаковыми (без учёта регистра) никами
+func handleNicknameDuplicates(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	groups := db.GetNicknameDuplicates()
+	if len(groups) == 0 {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Дубликатов ников нет 👍"))
+		return
+	}
+
+	text := "Одинаковые ники:\n"
+	for _, users := range groups {
+		text += fmt.Sprintf("\n«%s»:\n", users[0].Nickname)
+		for _, u := range users {
+			text += fmt.Sprintf("- %s, @%s, telegram_id=%d\n", u.Name, u.UserName, u.TelegramID)
+		}
+	}
+	text += "\nПопросите игроков сменить ник через /profile."
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+}
+
+func formatMembership(name, nickname string, m db.Membership) string {
+	games := "без ограничений"
+	if m.GamesIncluded > 0 {
+		games = fmt.Sprintf("%d из %d игр", m.GamesUsed, m.GamesIncluded)
 	}
+	return fmt.Sprintf("- %s (%s): %s до %s, %s", render.Escape(name), render.Escape(nickname), render.Escape(m.Type), locales.ClubTime(m.EndsAt).Format("02.01.2006"), games)
 }
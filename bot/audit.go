@@ -0,0 +1,123 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const auditViewLimit = 30
+
+// Записать действие администратора в журнал. Ошибка журнала не должна ломать само действие
+func audit(actorID int64, action, target string, before, after any) {
+	if err := db.AddAuditRecord(actorID, action, target, before, after); err != nil {
+		log.Println("audit error:", err)
+	}
+}
+
+// Фильтры вида: action=generate actor=@username days=7
+func parseAuditFilter(args string) (db.AuditFilter, error) {
+	filter := db.AuditFilter{Since: time.Now().AddDate(0, 0, -7)}
+
+	for _, arg := range strings.Fields(args) {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || value == "" {
+			return filter, fmt.Errorf("неверный фильтр «%s»", arg)
+		}
+		switch key {
+		case "action":
+			filter.Action = value
+		case "actor":
+			tgID, err := resolveTelegramID(value)
+			if err != nil {
+				return filter, err
+			}
+			filter.ActorID = tgID
+		case "days":
+			days, err := strconv.Atoi(value)
+			if err != nil || days <= 0 {
+				return filter, fmt.Errorf("days должно быть положительным числом")
+			}
+			filter.Since = time.Now().AddDate(0, 0, -days)
+		default:
+			return filter, fmt.Errorf("неизвестный фильтр «%s»", key)
+		}
+	}
+
+	return filter, nil
+}
+
+// /audit [action=...] [actor=...] [days=N]
+func handleAudit(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	filter, err := parseAuditFilter(msg.CommandArguments())
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Ошибка: %v\nФормат: /audit [action=generate] [actor=@username] [days=7]", err)))
+		return
+	}
+	filter.Limit = auditViewLimit
+
+	records := db.GetAuditRecords(filter)
+	if len(records) == 0 {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Записей в журнале нет."))
+		return
+	}
+
+	text := fmt.Sprintf("Журнал действий (последние %d):\n", len(records))
+	for _, r := range records {
+		actor := r.ActorName
+		if actor == "" {
+			actor = strconv.FormatInt(r.ActorID, 10)
+		}
+		text += fmt.Sprintf("\n%s — %s: %s", locales.ClubTime(r.CreatedAt).Format("02.01 15:04"), actor, r.Action)
+		if r.Target != "" {
+			text += fmt.Sprintf(" (%s)", r.Target)
+		}
+	}
+	text += "\n\nПолная выгрузка с данными до/после: /audit_export"
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+}
+
+// /audit_export [action=...] [actor=...] [days=N] — выгрузка журнала в CSV
+func handleAuditExport(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	filter, err := parseAuditFilter(msg.CommandArguments())
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Ошибка: %v\nФормат: /audit_export [action=generate] [actor=@username] [days=7]", err)))
+		return
+	}
+
+	records := db.GetAuditRecords(filter)
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"id", "created_at", "actor_telegram_id", "actor_name", "action", "target", "before", "after"})
+	for _, r := range records {
+		w.Write([]string{
+			strconv.Itoa(r.ID), r.CreatedAt.Format(time.RFC3339), strconv.FormatInt(r.ActorID, 10),
+			r.ActorName, r.Action, r.Target, r.Before, r.After,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Println("handleAuditExport csv error:", err)
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Ошибка: не удалось сформировать файл."))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("audit-%s.csv", locales.Now().Format("2006-01-02")),
+		Bytes: buf.Bytes(),
+	})
+	doc.Caption = fmt.Sprintf("Записей: %d", len(records))
+	if _, err := bot.Send(doc); err != nil {
+		log.Println("handleAuditExport send error:", err)
+	}
+}
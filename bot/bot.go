@@ -1,15 +1,18 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"laverdad-bot/db"
 	googleapi "laverdad-bot/google-api"
 	"laverdad-bot/locales"
+	"laverdad-bot/render"
+	"laverdad-bot/sender"
+	"laverdad-bot/services"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
@@ -17,13 +20,38 @@ import (
 type State string
 
 const (
-	StateNone          State = ""
-	StateEnterName     State = "enter_name"
-	StateEnterNickname State = "enter_nickname"
-	StateRegister      State = "register"
+	StateNone           State = ""
+	StateEnterName      State = "enter_name"
+	StateEnterNickname  State = "enter_nickname"
+	StateRegister       State = "register"
+	StateEditName       State = "edit_name"
+	StateEditNickname   State = "edit_nickname"
+	StateEditPhone      State = "edit_phone"
+	StateQuietHours     State = "quiet_hours"
+	StateGuestName      State = "guest_name"
+	StateTransferTarget State = "transfer_target"
 )
 
-var userStates = map[int64]State{}
+var (
+	userStates   = map[int64]State{}
+	userStatesMu sync.RWMutex
+)
+
+func getUserState(chatID int64) State {
+	userStatesMu.RLock()
+	defer userStatesMu.RUnlock()
+	return userStates[chatID]
+}
+
+func setUserState(chatID int64, state State) {
+	userStatesMu.Lock()
+	defer userStatesMu.Unlock()
+	if state == StateNone {
+		delete(userStates, chatID)
+		return
+	}
+	userStates[chatID] = state
+}
 
 var laVerdadChatID = -4863046517
 
@@ -32,6 +60,32 @@ func HandleUpdate(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
 		handleMessage(bot, update.Message)
 	} else if update.CallbackQuery != nil {
 		handleCallback(bot, update.CallbackQuery)
+	} else if update.InlineQuery != nil {
+		handleInlineQuery(bot, update.InlineQuery)
+	} else if update.ChosenInlineResult != nil {
+		handleChosenInlineResult(update.ChosenInlineResult)
+	} else if update.MyChatMember != nil {
+		handleMyChatMember(update.MyChatMember)
+	}
+}
+
+// Пользователь заблокировал или разблокировал бота в личном чате
+func handleMyChatMember(member *tgbotapi.ChatMemberUpdated) {
+	if !member.Chat.IsPrivate() {
+		return
+	}
+
+	var err error
+	switch member.NewChatMember.Status {
+	case "kicked":
+		log.Printf("User %d blocked the bot\n", member.Chat.ID)
+		err = db.MarkUserBlocked(member.Chat.ID)
+	case "member":
+		log.Printf("User %d unblocked the bot\n", member.Chat.ID)
+		err = db.MarkUserReachable(member.Chat.ID)
+	}
+	if err != nil {
+		log.Println(err)
 	}
 }
 
@@ -39,245 +93,386 @@ func handleMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
 	chatID := msg.Chat.ID
 	tgID := msg.From.ID
 	tgUser := msg.From
+	lang := userLang(tgUser)
 
 	// Проверяем состояние пользователя
-	state := userStates[chatID]
+	state := getUserState(chatID)
+
+	// Ссылку на событие могут открыть посреди регистрации в боте: это не ответ на вопрос,
+	// а /start с параметром — запоминаем событие и повторяем вопрос
+	if msg.Command() == "start" && (state == StateEnterName || state == StateEnterNickname) {
+		handleStart(bot, msg, state, lang)
+		return
+	}
 
 	switch state {
 	case StateEnterName:
-		db.UpdateUserName(int64(tgID), msg.Text)
-		userStates[chatID] = StateEnterNickname
-		sendText(bot, chatID, "Отлично! Теперь введи свой игровой *ник*:")
+		name, err := validateName(msg.Text, lang)
+		if err != nil {
+			sendText(bot, chatID, render.T(lang, "reg.retry_name", err))
+			return
+		}
+		db.UpdateUserName(int64(tgID), name)
+		setUserState(chatID, StateEnterNickname)
+		sendText(bot, chatID, render.T(lang, "reg.ask_nickname"))
 		return
 
 	case StateEnterNickname:
-		db.UpdateUserNickname(int64(tgID), msg.Text)
-		userStates[chatID] = StateNone
-		sendText(bot, chatID, "Готово! Теперь можешь использовать команды:\n/events — Список событий\n/my — Мои регистрации")
-		return
-	}
-
-	// Команды
-	switch msg.Text {
-	case "/start":
-		text := "Привет! Я бот клуба спортивной мафии *La Verdad*. \nС помощью меня можно записаться на игры и не только 😉"
-		sendText(bot, chatID, text)
-
-		// Если пользователь новый — добавляем и спрашиваем имя
-
-		user, err := db.GetOrCreateUser(int64(tgID), chatID, tgUser.UserName)
+		nickname, err := validateNickname(msg.Text, int64(tgID), lang)
 		if err != nil {
-			log.Println("db.GetOrCreateUser error:", err)
+			sendText(bot, chatID, render.T(lang, "reg.retry_nickname", err))
+			return
 		}
-		if (user.Name == "" || user.Nickname == "") && state == StateNone {
-			userStates[chatID] = StateEnterName
-			sendText(bot, chatID, "Пожалуйста пройди небольшую регистрацию\n\nВведи своё *имя*:")
+		if err := db.UpdateUserNickname(int64(tgID), nickname); err != nil {
+			log.Println("UpdateUserNickname error:", err)
+			sendText(bot, chatID, render.T(lang, "reg.nickname_save_failed"))
 			return
 		}
+		setUserState(chatID, StateNone)
+		sendText(bot, chatID, render.T(lang, "reg.done"))
+		// Пользователь пришёл по ссылке на событие — теперь можно его показать
+		resumeStart(bot, chatID, tgID, lang)
+		return
+	}
+
+	if handleProfileInput(bot, msg, state, lang) {
+		return
+	}
+	if handleSettingsInput(bot, msg, state, lang) {
+		return
+	}
+	if handleGuestInput(bot, msg, state, lang) {
+		return
+	}
+	if handleTransferInput(bot, msg, state, lang) {
+		return
+	}
+
+	// /start может прийти с параметром из ссылки t.me/<бот>?start=...
+	if msg.Command() == "start" {
+		handleStart(bot, msg, state, lang)
+		return
+	}
 
+	// Команды
+	switch msg.Text {
 	case "/events":
 		events := db.GetEvents()
 		if len(events) == 0 {
-			sendText(bot, chatID, "Пока нет доступных событий.")
+			sendText(bot, chatID, render.T(lang, "events.none"))
 			return
 		}
 
-		text := "Доступные мероприятия:\n\n"
+		text := render.T(lang, "events.title")
 		markup := tgbotapi.NewInlineKeyboardMarkup()
 		var rows [][]tgbotapi.InlineKeyboardButton
 		for _, e := range events {
 			row := tgbotapi.NewInlineKeyboardRow(
-				tgbotapi.NewInlineKeyboardButtonData(
+				callbackButton(
 					fmt.Sprintf("%s — %s", e.Title, e.StartsAt.Format("02.01 15:04")),
-					fmt.Sprintf("ev_%d", e.ID),
+					ActionEvent, e.ID,
 				),
 			)
 			rows = append(rows, row)
 		}
 		markup.InlineKeyboard = rows
-		msg := tgbotapi.NewMessage(chatID, text)
+		msg := render.Message(chatID, text)
 		msg.ReplyMarkup = markup
 		bot.Send(msg)
 
 	case "/my":
 		registrations := db.GetUserRegistrations(int64(tgID))
 		if len(registrations) == 0 {
-			sendText(bot, chatID, "Ты пока никуда не записан.")
+			sendText(bot, chatID, render.T(lang, "my.none"))
 			return
 		}
 		for _, r := range registrations {
-			text := fmt.Sprintf("*%s*\nСтарт: %s", r.Title, r.StartsAt.Format("02.01.2006 15:04"))
-			btn := tgbotapi.NewInlineKeyboardMarkup(
-				tgbotapi.NewInlineKeyboardRow(
-					tgbotapi.NewInlineKeyboardButtonData("Отменить", fmt.Sprintf("cancel_%d", r.ID)),
-				),
-			)
-			msg := tgbotapi.NewMessage(chatID, text)
-			msg.ParseMode = "Markdown"
-			msg.ReplyMarkup = btn
+			text, markup := myRegistration(r, tgID, lang)
+			msg := render.Message(chatID, text)
+			msg.ReplyMarkup = markup
 			bot.Send(msg)
 		}
 
+	case "/profile":
+		showProfile(bot, chatID, tgID)
+
+	case "/settings":
+		showSettings(bot, chatID, tgID, lang)
+
+	case "/subscriptions":
+		showSubscriptions(bot, chatID, tgID, lang)
+
+	case "/invite":
+		handleInvite(bot, chatID, tgID, lang)
+
+	case "/language":
+		showLanguages(bot, chatID, lang)
+
+	case "/mydata":
+		handleMyData(bot, chatID, tgID, lang)
+
+	case "/forget_me":
+		handleForgetMe(bot, chatID, lang)
+
 	default:
 		// проверяем админа
 		if IsAdmin(tgID) {
 			HandleAdmin(bot, msg)
 			return
 		}
-		sendText(bot, chatID, "Неизвестная команда. Доступные команды:\n/events — список событий\n/my — мои регистрации")
+		sendText(bot, chatID, render.T(lang, "help.unknown"))
 	}
 }
 
 func handleCallback(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery) {
-	data := callback.Data
-	chatID := callback.Message.Chat.ID
 	tgID := callback.From.ID
+	lang := userLang(callback.From)
+
+	cb, err := decodeCallback(callback.Data, time.Now())
+	if err != nil {
+		log.Printf("handleCallback: rejected callback %q from %d: %v\n", callback.Data, tgID, err)
+		answer := tgbotapi.NewCallback(callback.ID, locales.T(lang, "callback.expired_alert"))
+		answer.ShowAlert = true
+		bot.Request(answer)
+		return
+	}
+
+	// Кнопка под сообщением в группе или отправленным через inline-режим: отвечаем в личку
+	if callback.Message == nil || !callback.Message.Chat.IsPrivate() {
+		handleSharedCallback(bot, callback, cb, lang)
+		return
+	}
+
+	chatID := callback.Message.Chat.ID
 	mesgID := callback.Message.MessageID
 
-	if strings.HasPrefix(data, "ev_") {
-		eventIDStr := strings.TrimPrefix(data, "ev_")
-		eventID, _ := strconv.Atoi(eventIDStr)
+	switch cb.Action {
+	case ActionEvent:
+		eventID, err := cb.ID()
+		if err != nil {
+			bot.Request(tgbotapi.NewCallback(callback.ID, locales.T(lang, "callback.expired")))
+			return
+		}
 
 		ev, err := db.FetchEvent(int64(eventID))
 		if err != nil {
 			log.Println("fetchEvent:", err)
-			sendText(bot, chatID, "Ошибка: Не удалось загрузить событие.")
+			sendText(bot, chatID, render.T(lang, "event.load_failed"))
 			return
 		}
 
-		// Already registered?
-		user := db.GetUser(tgID)
-		regExists := db.RegistrationExists(int64(eventID), int64(user.ID))
-
-		text := ev.Description
-
-		if regExists {
-			text += "\n\n✅ *Вы уже зарегистрированы*"
-		}
-
-		edit := tgbotapi.NewEditMessageText(chatID, mesgID, text)
-		edit.ParseMode = "Markdown"
-
-		// Кнопка для начала регистрации
-		if !regExists {
-			btn := tgbotapi.NewInlineKeyboardMarkup(
-				tgbotapi.NewInlineKeyboardRow(
-					tgbotapi.NewInlineKeyboardButtonData("📝 Записаться", fmt.Sprintf("register_%d", eventID)),
-				),
-			)
-			edit.ReplyMarkup = &btn
-		}
+		text, markup := eventCard(ev, tgID, lang)
+		edit := render.Edit(chatID, mesgID, text)
+		edit.ReplyMarkup = markup
 
 		if _, err := bot.Send(edit); err != nil {
 			log.Println("Event list event:", err)
 		}
 
 		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
-		return
 
-	} else if strings.HasPrefix(data, "admin_ev_") {
-		eventIDStr := strings.TrimPrefix(data, "admin_ev_")
-		eventID, _ := strconv.Atoi(eventIDStr)
+	case ActionAdminEvent:
+		if !HasPermission(tgID, PermViewRegistrations) {
+			bot.Request(tgbotapi.NewCallback(callback.ID, locales.T(lang, "callback.forbidden")))
+			return
+		}
 
-		event, err := db.FetchEvent(int64(eventID))
+		eventID, err := cb.ID()
 		if err != nil {
-			sendText(bot, chatID, "Ошибка: Не удалось загрузить событие.")
+			bot.Request(tgbotapi.NewCallback(callback.ID, locales.T(lang, "callback.expired")))
 			return
 		}
 
-		text := fmt.Sprintf("%s — %s\n", event.Title, event.StartsAt.Format("02.01 15:04"))
+		event, err := db.FetchEvent(int64(eventID))
+		if err != nil {
+			sendText(bot, chatID, render.T(lang, "event.load_failed"))
+			return
+		}
 
 		regs := db.GetRegistrationsByEvent(eventID)
-		if len(regs) == 0 {
-			text += "Нет регистраций на мероприятие!"
-		} else {
-			for _, r := range regs {
-				text += fmt.Sprintf("- [%s  (%s)](tg://user?id=%s)\n", r.Name, r.Nickname, strconv.Itoa(int(r.TelegramID)))
+		memberships := 0
+		for _, r := range regs {
+			if r.Membership {
+				memberships++
 			}
 		}
+		text := render.EventRegistrations.Render(struct {
+			Event                  db.Event
+			Link                   string
+			Registrations          []db.AdminRegistration
+			Memberships, Donations int
+		}{event, eventLink(bot, event.ID), regs, memberships, len(regs) - memberships})
 
 		sendText(bot, chatID, text)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
 
-		return
-
-	} else if strings.HasPrefix(data, "register_") {
-		eventIDStr := strings.TrimPrefix(data, "register_")
-		eventID, _ := strconv.Atoi(eventIDStr)
-
-		err := db.RegisterUserToEvent(int64(tgID), eventID)
+	case ActionRegister:
+		eventID, err := cb.ID()
 		if err != nil {
-			sendText(bot, chatID, fmt.Sprintf("RegisterUserToEvent Error: %v", err))
+			bot.Request(tgbotapi.NewCallback(callback.ID, locales.T(lang, "callback.expired")))
 			return
 		}
 
-		event, err := db.FetchEvent(int64(eventID))
-		if err == nil {
-			sheetName := fmt.Sprintf("%s - %s", event.Title, event.StartsAt.Format("02.01"))
-			line, _ := db.GetRegistrationLine(int(tgID), eventID)
+		bot.Request(tgbotapi.NewCallback(callback.ID, registerForEvent(bot, chatID, tgID, eventID, lang)))
 
-			go googleapi.AddRegistrationToSheet(sheetName, line)
-			sendText(bot, chatID, "✅ Ты успешно зарегистрирован на событие!")
-		} else {
-			log.Printf("Error FetchEvent with id=%d\n", int(eventID))
+	case ActionProfile:
+		handleProfileCallback(bot, chatID, cb.Arg, lang)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+
+	case ActionSettings:
+		handleSettingsCallback(bot, chatID, tgID, mesgID, cb.Arg, lang)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+
+	case ActionLanguage:
+		handleLanguageCallback(bot, chatID, tgID, mesgID, cb.Arg)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+
+	case ActionGuest:
+		handleGuestCallback(bot, chatID, tgID, mesgID, cb.Arg, lang)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+
+	case ActionTransfer:
+		handleTransferCallback(bot, chatID, tgID, mesgID, cb.Arg, lang)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+
+	case ActionSubscription:
+		handleSubscriptionCallback(bot, chatID, tgID, mesgID, cb.Arg, lang)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+
+	case ActionForget:
+		handleForgetCallback(bot, chatID, tgID, mesgID, cb.Arg == "confirm", lang)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+
+	case ActionBroadcast:
+		if !HasPermission(tgID, PermNotify) {
+			bot.Request(tgbotapi.NewCallback(callback.ID, locales.T(lang, "callback.forbidden")))
+			return
 		}
+		handleBroadcastCallback(bot, chatID, tgID, cb.Arg)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
 
-	} else if strings.HasPrefix(data, "cancel_") {
-		eventIDStr := strings.TrimPrefix(data, "cancel_")
-		eventID, _ := strconv.Atoi(eventIDStr)
+	case ActionCancel:
+		eventID, err := cb.ID()
+		if err != nil {
+			bot.Request(tgbotapi.NewCallback(callback.ID, locales.T(lang, "callback.expired")))
+			return
+		}
 
 		regID := db.GetRegistrationID(int64(tgID), eventID)
 		event, _ := db.FetchEvent(int64(eventID))
-		sheetName := fmt.Sprintf("%s - %s", event.Title, event.StartsAt.Format("02.01"))
-		go googleapi.UpdateRegistrationStateToSheet(regID, sheetName, time.Now())
+		sheetName := googleapi.SheetName(event.Title, event.StartsAt)
+		// Гости удаляются вместе с регистрацией игрока — отмечаем отмену и их строк
+		guests := db.GetGuests(int64(tgID), eventID)
+		googleapi.Async(func() {
+			googleapi.UpdateRegistrationStateToSheet(regID, sheetName, time.Now())
+			for _, g := range guests {
+				googleapi.UpdateRegistrationStateToSheet(g.RegistrationID, sheetName, time.Now())
+			}
+		})
 
-		err := db.CancelUserRegistration(int64(tgID), eventID)
+		err = db.CancelUserRegistration(int64(tgID), eventID)
 		if err != nil {
-			sendText(bot, chatID, fmt.Sprintf("Ошибка: %v", err))
+			log.Println("CancelUserRegistration error:", err)
+			sendText(bot, chatID, render.T(lang, "cancel.failed"))
 			return
 		}
 
-		sendText(bot, chatID, "❌ Регистрация отменена.")
+		sendText(bot, chatID, render.T(lang, "cancel.done"))
+		refreshGroupAnnouncements(bot, eventID)
+		bot.Request(tgbotapi.NewCallback(callback.ID, ""))
+	}
+}
+
+// Записать игрока на событие. Ответы отправляются в чат chatID,
+// возвращается текст всплывающего уведомления для callback
+func registerForEvent(bot *tgbotapi.BotAPI, chatID int64, tgID int64, eventID int, lang locales.Lang) string {
+	event, err := db.FetchEvent(int64(eventID))
+	if err != nil {
+		log.Printf("Error FetchEvent with id=%d\n", int(eventID))
+		sendText(bot, chatID, render.T(lang, "event.load_failed"))
+		return ""
+	}
+	if !event.StartsAt.After(time.Now()) {
+		return locales.T(lang, "register.closed")
+	}
+	// Подписчики записаны на игры серии заранее — повторное нажатие не ошибка
+	if db.RegistrationExists(int64(eventID), int64(db.GetUser(tgID).ID)) {
+		return locales.T(lang, "register.already")
+	}
+
+	err = db.RegisterUserToEvent(tgID, eventID)
+	if err != nil {
+		log.Println("RegisterUserToEvent error:", err)
+		sendText(bot, chatID, render.T(lang, "register.failed"))
+		return ""
+	}
+
+	line, _ := db.GetRegistrationLine(int(tgID), eventID)
+	addRegistrationToSheet(event, line)
+	text := render.T(lang, "register.success")
+	if line.MembershipID.Valid {
+		text += render.T(lang, "register.membership")
 	}
+	sendText(bot, chatID, text)
+	refreshGroupAnnouncements(bot, eventID)
+	return locales.T(lang, "register.toast")
+}
 
-	cb := tgbotapi.NewCallback(callback.ID, "Регистрация успешна!")
-	bot.Send(cb)
+func addRegistrationToSheet(event db.Event, line db.RegistrationLine) {
+	sheetName := googleapi.SheetName(event.Title, event.StartsAt)
+	googleapi.Async(func() {
+		if err := googleapi.AddRegistrationToSheet(sheetName, line); err != nil {
+			log.Println("AddRegistrationToSheet error:", err)
+		}
+	})
+}
+
+// Карточка события с кнопкой записи, если игрок ещё не записан
+func eventCard(ev db.Event, tgID int64, lang locales.Lang) (string, *tgbotapi.InlineKeyboardMarkup) {
+	user := db.GetUser(tgID)
+	text := ev.DescriptionIn(lang)
+	if db.RegistrationExists(int64(ev.ID), int64(user.ID)) {
+		return text + render.T(lang, "event.already_registered"), nil
+	}
+
+	btn := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton(locales.T(lang, "event.register"), ActionRegister, ev.ID),
+		),
+	)
+	return text, &btn
 }
 
 func sendText(bot *tgbotapi.BotAPI, chatID int64, text string) {
-	msg := tgbotapi.NewMessage(chatID, text)
-	msg.ParseMode = "Markdown"
-	if _, err := bot.Send(msg); err != nil {
+	if _, err := bot.Send(render.Message(chatID, text)); err != nil {
 		log.Println("sendText error:", err)
 	}
 }
 
-func StartNotifications(bot *tgbotapi.BotAPI) {
+// Шаблоны напоминаний по типу уведомления
+var reminderTemplates = map[string]*render.Named{
+	db.KindReminder24: render.Reminder24,
+	db.KindReminder3:  render.Reminder3,
+	db.KindReminder1:  render.Reminder1,
+}
+
+// Сколько уведомлений забирать из очереди за один проход
+const notificationBatch = 100
+
+// Проверять очередь уведомлений каждую минуту, пока не отменён ctx
+func StartNotifications(ctx context.Context, bot *tgbotapi.BotAPI) {
 	ticker := time.NewTicker(time.Minute) // проверяем каждую минуту
 	defer ticker.Stop()
 
-	// Настройки для разных типов напоминаний
-	reminders := []struct {
-		duration   time.Duration
-		statusFlag string
-		messageFmt string
-	}{
-		{
-			duration:   24 * time.Hour,
-			statusFlag: "reminder24_sent",
-			messageFmt: "Напоминание! Завтра в %s начнется: %s",
-		},
-		{
-			duration:   1 * time.Hour,
-			statusFlag: "reminder1_sent",
-			messageFmt: "Напоминание! Через час начнется: %s",
-		},
-	}
-
-	for range ticker.C {
-		for _, r := range reminders {
-			processReminder(bot, r.duration, r.statusFlag, r.messageFmt)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Notifications stopped")
+			return
+		case <-ticker.C:
+			processScheduledNotifications()
+			processQuorum(bot)
 		}
-		processQuorum(bot)
 	}
 }
 
@@ -289,58 +484,101 @@ func processQuorum(bot *tgbotapi.BotAPI) {
 			log.Printf("Error processQuorum for event.id=%d, error: %v\n", e.ID, err)
 			continue
 		}
-		// if registrations >= 12 send notification
+		// if registrations >= 12 send notification once
 		if count >= 12 {
+			claimed, err := db.ClaimQuorum(e.ID)
+			if err != nil {
+				log.Printf("Error processQuorum for event.id=%d, error: %v\n", e.ID, err)
+				continue
+			}
+			if !claimed {
+				continue
+			}
 			users, err := db.GetEventParticipants(e.ID)
 			if err != nil {
 				log.Printf("Error processQuorum for event.id=%d, error: %v\n", e.ID, err)
 				continue
 			}
-			timeText := locales.FormatDateShortRU(e.StartsAt) + e.StartsAt.Format("🕐 15:04.")
-			text := fmt.Sprintf(`Есть кворум!
-
-%s
-🗓 %s
-📌 %s
-💶 Донат на развитие клуба - 5€ с человека.
-
-Постарайтесь не опоздать. Если что-то поменяется, обязательно напишите. Ждём! 🕵️‍♂️
-`, e.Title, timeText, e.Location)
+			text := render.Quorum.Render(locales.DefaultLang, render.QuorumData{
+				Event:   render.Event(e, locales.DefaultLang),
+				Players: render.Players(users),
+				Count:   len(users),
+			})
+			sender.Send(int64(laVerdadChatID), render.Message(int64(laVerdadChatID), text))
+		}
+	}
+}
 
-			for i, u := range users {
-				text += fmt.Sprintf("%d) @%s\n", i+1, u.Nickname)
-			}
-			sendText(bot, int64(laVerdadChatID), text)
+func processScheduledNotifications() {
+	for {
+		due, err := db.ClaimDueNotifications(notificationBatch)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		for _, n := range due {
+			deliverNotification(n)
+		}
+		if len(due) < notificationBatch {
+			return
 		}
 	}
 }
 
-func processReminder(bot *tgbotapi.BotAPI, duration time.Duration, statusFlag, messageFmt string) {
-	events := db.GetUpcomingEvents(duration)
-	var eventIDs []string
+func deliverNotification(n db.DueNotification) {
+	now := locales.Now()
+	settings := db.GetUserSettings(n.TelegramID)
+
+	var msg tgbotapi.MessageConfig
+	lang := locales.ParseLang(n.Language)
+	switch n.Kind {
+	case db.KindNewWeek:
+		msg = render.Message(n.ChatID, services.NewWeekText(lang))
+	case db.KindSubscribed, db.KindNoSeat:
+		msg = services.SubscriptionMessage(n, lang)
+	default:
+		if tmpl := reminderTemplates[n.Kind]; tmpl != nil {
+			event := db.Event{Title: n.EventTitle, Location: n.EventLocation, StartsAt: n.EventStartsAt}
+			msg = render.Message(n.ChatID, tmpl.Render(lang, render.EventData{Event: render.Event(event, lang)}))
+		}
+	}
 
-	for _, e := range events {
-		eventIDs = append(eventIDs, strconv.Itoa(e.ID))
-		users := db.GetEventParticipantsWithFlag(e.ID, statusFlag)
-
-		for _, u := range users {
-			var text string
-			if duration == 24*time.Hour {
-				// для суток добавляем время
-				text = fmt.Sprintf(messageFmt, e.StartsAt.Format("15:04 02.01.2006"), e.Title)
-			} else {
-				text = fmt.Sprintf(messageFmt, e.Title)
-			}
-			msg := tgbotapi.NewMessage(u.ChatID, text)
-			if _, err := bot.Send(msg); err != nil {
-				log.Printf("Ошибка отправки сообщения пользователю %d: %v", u.ChatID, err)
+	skip := ""
+	switch {
+	case !n.EventStartsAt.IsZero() && !now.Before(n.EventStartsAt):
+		skip = "event started"
+	case n.Blocked || n.ChatID <= 0:
+		skip = "user unreachable"
+	case !settings.Wants(n.Kind):
+		skip = "disabled in settings"
+	case msg.Text == "":
+		skip = "nothing to send"
+	case db.InQuietHours(settings.QuietFrom, settings.QuietTo, now):
+		// Тихие часы: откладываем до их окончания, если игра к тому времени ещё не начнётся.
+		// Уведомления без регистрации к игре не привязаны и откладываются всегда
+		if end := db.QuietHoursEnd(settings.QuietTo, now); n.EventStartsAt.IsZero() || end.Before(n.EventStartsAt) {
+			if err := db.RescheduleNotification(n.ID, end); err != nil {
+				log.Println(err)
 			}
+			return
 		}
+		skip = "quiet hours"
 	}
-
-	if len(eventIDs) > 0 {
-		if err := db.UpdateRegistrationNotificationStatus(eventIDs, statusFlag); err != nil {
+	if skip != "" {
+		if err := db.SkipNotification(n.ID, skip); err != nil {
 			log.Println(err)
 		}
+		return
 	}
+
+	sender.Enqueue(n.ChatID, msg, func(err error) {
+		if err != nil {
+			err = db.MarkNotificationFailed(n.ID, err)
+		} else {
+			err = db.MarkNotificationSent(n.ID)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	})
 }
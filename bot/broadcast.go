@@ -0,0 +1,212 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"laverdad-bot/db"
+	"laverdad-bot/render"
+	"laverdad-bot/sender"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type BroadcastDraft struct {
+	Text     string
+	PhotoID  string
+	Audience db.Audience
+}
+
+func startBroadcast(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state *AdminState) {
+	state.Step = "broadcast_message"
+	state.Broadcast = BroadcastDraft{}
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Отправьте сообщение для рассылки: текст или фото с подписью. Можно использовать HTML-разметку: <b>жирный</b>, <i>курсив</i>, <a href=\"https://…\">ссылка</a>. Символы <, > и & без разметки пишите как &lt;, &gt; и &amp;.\n/cancel — отменить"))
+}
+
+func handleBroadcastMessage(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state *AdminState) {
+	switch {
+	case len(msg.Photo) > 0:
+		// Последний размер — самый большой
+		state.Broadcast.PhotoID = msg.Photo[len(msg.Photo)-1].FileID
+		state.Broadcast.Text = msg.Caption
+	case msg.Text != "":
+		state.Broadcast.PhotoID = ""
+		state.Broadcast.Text = msg.Text
+	default:
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Поддерживаются только текст и фото. Попробуйте ещё раз:"))
+		return
+	}
+
+	state.Step = "broadcast_audience"
+	text := tgbotapi.NewMessage(msg.Chat.ID, "Кому отправить?")
+	text.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(callbackButton("👥 Всем", ActionBroadcast, string(db.AudienceAll))),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🗓 Записавшимся на событие", ActionBroadcast, string(db.AudienceEvent))),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🔥 Игравшим за последние N недель", ActionBroadcast, string(db.AudienceActive))),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("🎫 Владельцам абонементов", ActionBroadcast, string(db.AudienceMembers))),
+		tgbotapi.NewInlineKeyboardRow(callbackButton("❌ Отмена", ActionBroadcast, "cancel")),
+	)
+	bot.Send(text)
+}
+
+func handleBroadcastWeeks(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state *AdminState) {
+	weeks, err := strconv.Atoi(strings.TrimSpace(msg.Text))
+	if err != nil || weeks <= 0 {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Введите количество недель числом:"))
+		return
+	}
+	state.Broadcast.Audience = db.Audience{Kind: db.AudienceActive, Weeks: weeks}
+	previewBroadcast(bot, msg.Chat.ID, state)
+}
+
+func handleBroadcastCallback(bot *tgbotapi.BotAPI, chatID int64, tgID int64, arg string) {
+	state := getAdminState(tgID)
+	if !strings.HasPrefix(state.Step, "broadcast_") {
+		bot.Send(tgbotapi.NewMessage(chatID, "Рассылка не найдена. Начните заново: /broadcast"))
+		return
+	}
+
+	switch {
+	case arg == "cancel":
+		state.Step = ""
+		state.Broadcast = BroadcastDraft{}
+		bot.Send(tgbotapi.NewMessage(chatID, "Рассылка отменена."))
+	case arg == "send":
+		if state.Step != "broadcast_confirm" {
+			return
+		}
+		draft := state.Broadcast
+		state.Step = ""
+		state.Broadcast = BroadcastDraft{}
+		sendBroadcast(bot, chatID, tgID, draft)
+	case arg == string(db.AudienceAll), arg == string(db.AudienceMembers):
+		state.Broadcast.Audience = db.Audience{Kind: db.AudienceKind(arg)}
+		previewBroadcast(bot, chatID, state)
+	case arg == string(db.AudienceActive):
+		state.Step = "broadcast_weeks"
+		bot.Send(tgbotapi.NewMessage(chatID, "За сколько последних недель учитывать игры?"))
+	case arg == string(db.AudienceEvent):
+		events := db.GetEvents()
+		if len(events) == 0 {
+			bot.Send(tgbotapi.NewMessage(chatID, "Пока нет доступных событий."))
+			return
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, e := range events {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				callbackButton(fmt.Sprintf("%s — %s", e.Title, e.StartsAt.Format("02.01 15:04")), ActionBroadcast, fmt.Sprintf("event-%d", e.ID)),
+			))
+		}
+		msg := tgbotapi.NewMessage(chatID, "Выберите событие:")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+		bot.Send(msg)
+	case strings.HasPrefix(arg, "event-"):
+		eventID, err := strconv.Atoi(strings.TrimPrefix(arg, "event-"))
+		if err != nil {
+			return
+		}
+		state.Broadcast.Audience = db.Audience{Kind: db.AudienceEvent, EventID: eventID}
+		previewBroadcast(bot, chatID, state)
+	}
+}
+
+// Показать администратору сообщение в том виде, в каком его получат игроки, и число получателей
+func previewBroadcast(bot *tgbotapi.BotAPI, chatID int64, state *AdminState) {
+	recipients, blocked, err := db.GetBroadcastAudience(state.Broadcast.Audience)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+
+	if _, err := bot.Send(buildBroadcastMessage(chatID, state.Broadcast)); err != nil {
+		state.Step = "broadcast_message"
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Telegram не принял сообщение (%v). Проверьте разметку и отправьте сообщение ещё раз:", err)))
+		return
+	}
+
+	state.Step = "broadcast_confirm"
+	text := fmt.Sprintf("☝️ Так выглядит рассылка.\nАудитория: %s\nПолучателей: %d", audienceTitle(state.Broadcast.Audience), len(recipients))
+	if blocked > 0 {
+		text += fmt.Sprintf("\nЗаблокировали бота (не получат): %d", blocked)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton("✅ Отправить", ActionBroadcast, "send"),
+			callbackButton("❌ Отмена", ActionBroadcast, "cancel"),
+		),
+	)
+	bot.Send(msg)
+}
+
+func sendBroadcast(bot *tgbotapi.BotAPI, chatID int64, tgID int64, draft BroadcastDraft) {
+	recipients, blocked, err := db.GetBroadcastAudience(draft.Audience)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	if len(recipients) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "Получателей нет, рассылка не отправлена."))
+		return
+	}
+
+	audit(tgID, "broadcast", audienceTitle(draft.Audience), nil, draft)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🚀 Рассылка поставлена в очередь: %d получателей. Пришлю отчёт, когда закончу.", len(recipients))))
+
+	var mu sync.Mutex
+	sent, failed, remaining := 0, 0, len(recipients)
+	for _, recipient := range recipients {
+		sender.Enqueue(recipient, buildBroadcastMessage(recipient, draft), func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				failed++
+			} else {
+				sent++
+			}
+			remaining--
+			if remaining == 0 {
+				report := fmt.Sprintf("📬 Рассылка завершена (%s):\n✅ Доставлено: %d\n⚠️ Ошибки: %d\n🚫 Заблокировали бота: %d",
+					audienceTitle(draft.Audience), sent, failed, blocked)
+				if _, err := bot.Send(tgbotapi.NewMessage(chatID, report)); err != nil {
+					log.Println("sendBroadcast report error:", err)
+				}
+			}
+		})
+	}
+}
+
+// Текст рассылки целиком пишет администратор, поэтому он отправляется в HTML как есть,
+// без экранирования: подставлять в него нечего, и ошибку разметки видно на предпросмотре
+func buildBroadcastMessage(chatID int64, draft BroadcastDraft) tgbotapi.Chattable {
+	if draft.PhotoID != "" {
+		photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileID(draft.PhotoID))
+		photo.Caption = draft.Text
+		photo.ParseMode = render.ParseMode
+		return photo
+	}
+	msg := tgbotapi.NewMessage(chatID, draft.Text)
+	msg.ParseMode = render.ParseMode
+	return msg
+}
+
+func audienceTitle(a db.Audience) string {
+	switch a.Kind {
+	case db.AudienceAll:
+		return "все пользователи"
+	case db.AudienceEvent:
+		if e, err := db.FetchEvent(int64(a.EventID)); err == nil {
+			return fmt.Sprintf("записавшиеся на «%s» %s", e.Title, e.StartsAt.Format("02.01"))
+		}
+		return fmt.Sprintf("записавшиеся на событие #%d", a.EventID)
+	case db.AudienceActive:
+		return fmt.Sprintf("игравшие за последние %d нед.", a.Weeks)
+	case db.AudienceMembers:
+		return "владельцы абонементов"
+	}
+	return string(a.Kind)
+}
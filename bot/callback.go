@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Формат callback data: <версия>:<действие>:<аргумент>:<срок годности>:<подпись>.
+// Подпись — усечённый HMAC-SHA256 от всех предыдущих полей, поэтому клиент
+// не может подделать кнопку или поменять в ней id события.
+// Telegram ограничивает callback data 64 байтами.
+
+type CallbackAction string
+
+const (
+	ActionEvent        CallbackAction = "ev"
+	ActionRegister     CallbackAction = "reg"
+	ActionCancel       CallbackAction = "cnl"
+	ActionAdminEvent   CallbackAction = "aev"
+	ActionProfile      CallbackAction = "prf"
+	ActionForget       CallbackAction = "fgt"
+	ActionBroadcast    CallbackAction = "bc"
+	ActionSettings     CallbackAction = "set"
+	ActionLanguage     CallbackAction = "lng"
+	ActionGuest        CallbackAction = "gst"
+	ActionTransfer     CallbackAction = "trf"
+	ActionSubscription CallbackAction = "sub"
+)
+
+const (
+	callbackVersion  = "1"
+	callbackSigLen   = 10
+	callbackMaxBytes = 64
+)
+
+// Сколько живёт кнопка каждого типа
+var callbackTTL = map[CallbackAction]time.Duration{
+	ActionEvent:        14 * 24 * time.Hour,
+	ActionRegister:     14 * 24 * time.Hour,
+	ActionCancel:       30 * 24 * time.Hour,
+	ActionAdminEvent:   7 * 24 * time.Hour,
+	ActionProfile:      24 * time.Hour,
+	ActionForget:       time.Hour,
+	ActionBroadcast:    24 * time.Hour,
+	ActionSettings:     30 * 24 * time.Hour,
+	ActionLanguage:     30 * 24 * time.Hour,
+	ActionGuest:        14 * 24 * time.Hour,
+	ActionTransfer:     14 * 24 * time.Hour,
+	ActionSubscription: 30 * 24 * time.Hour,
+}
+
+var (
+	ErrCallbackMalformed = errors.New("malformed callback data")
+	ErrCallbackVersion   = errors.New("unsupported callback version")
+	ErrCallbackSignature = errors.New("invalid callback signature")
+	ErrCallbackExpired   = errors.New("callback expired")
+	ErrCallbackTooLong   = errors.New("callback data exceeds 64 bytes")
+	ErrCallbackSeparator = errors.New("callback argument contains the separator")
+)
+
+type Callback struct {
+	Action    CallbackAction
+	Arg       string
+	ExpiresAt time.Time
+}
+
+// Аргумент как id (события, регистрации)
+func (c Callback) ID() (int, error) {
+	id, err := strconv.Atoi(c.Arg)
+	if err != nil || id <= 0 {
+		return 0, ErrCallbackMalformed
+	}
+	return id, nil
+}
+
+var callbackSecret []byte
+
+// Если секрет не задан, генерируется случайный — тогда все кнопки устаревают после перезапуска бота
+func InitCallbacks(secret string) {
+	if secret != "" {
+		callbackSecret = []byte(secret)
+		return
+	}
+
+	log.Println("CALLBACK_SECRET не задан, используется случайный ключ: кнопки перестанут работать после перезапуска")
+	callbackSecret = make([]byte, 32)
+	if _, err := rand.Read(callbackSecret); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func signCallback(payload string) string {
+	mac := hmac.New(sha256.New, callbackSecret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSigLen])
+}
+
+func encodeCallback(action CallbackAction, arg string, now time.Time) (string, error) {
+	// «:» разделяет части данных; молча выбросить его — значит испортить аргумент
+	if strings.Contains(arg, ":") {
+		return "", fmt.Errorf("%w: %q", ErrCallbackSeparator, arg)
+	}
+
+	exp := now.Add(callbackTTL[action]).Unix()
+	payload := strings.Join([]string{callbackVersion, string(action), arg, strconv.FormatInt(exp, 36)}, ":")
+	data := payload + ":" + signCallback(payload)
+	if len(data) > callbackMaxBytes {
+		return "", fmt.Errorf("%w: %s", ErrCallbackTooLong, data)
+	}
+	return data, nil
+}
+
+func decodeCallback(data string, now time.Time) (Callback, error) {
+	var cb Callback
+
+	parts := strings.Split(data, ":")
+	if len(parts) != 5 {
+		return cb, ErrCallbackMalformed
+	}
+	if parts[0] != callbackVersion {
+		return cb, ErrCallbackVersion
+	}
+
+	payload := strings.Join(parts[:4], ":")
+	if !hmac.Equal([]byte(parts[4]), []byte(signCallback(payload))) {
+		return cb, ErrCallbackSignature
+	}
+
+	cb.Action = CallbackAction(parts[1])
+	if _, ok := callbackTTL[cb.Action]; !ok {
+		return cb, ErrCallbackMalformed
+	}
+	cb.Arg = parts[2]
+
+	exp, err := strconv.ParseInt(parts[3], 36, 64)
+	if err != nil {
+		return cb, ErrCallbackMalformed
+	}
+	cb.ExpiresAt = time.Unix(exp, 0)
+	if now.After(cb.ExpiresAt) {
+		return cb, ErrCallbackExpired
+	}
+
+	return cb, nil
+}
+
+// Кнопка с подписанными callback data. Слишком длинный аргумент — ошибка в коде бота:
+// такая кнопка не создаётся, и Telegram отклонит сообщение с пустой кнопкой, а не с неподписанной
+func callbackButton(text string, action CallbackAction, arg any) tgbotapi.InlineKeyboardButton {
+	data, err := encodeCallback(action, fmt.Sprint(arg), time.Now())
+	if err != nil {
+		log.Printf("callbackButton %q: %v\n", text, err)
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(text, data)
+}
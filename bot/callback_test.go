@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func init() {
+	callbackSecret = []byte("test-secret")
+}
+
+var testNow = time.Date(2025, 10, 24, 18, 30, 0, 0, time.UTC)
+
+func mustEncode(t *testing.T, action CallbackAction, arg string) string {
+	t.Helper()
+	data, err := encodeCallback(action, arg, testNow)
+	if err != nil {
+		t.Fatalf("encodeCallback(%s, %q): %v", action, arg, err)
+	}
+	return data
+}
+
+func TestCallbackRoundTrip(t *testing.T) {
+	for action, ttl := range callbackTTL {
+		data := mustEncode(t, action, "12345")
+		if len(data) > callbackMaxBytes {
+			t.Errorf("%s: %d bytes, limit %d", action, len(data), callbackMaxBytes)
+		}
+
+		cb, err := decodeCallback(data, testNow)
+		if err != nil {
+			t.Fatalf("%s: decodeCallback: %v", action, err)
+		}
+		if cb.Action != action || cb.Arg != "12345" {
+			t.Errorf("%s: got %s %q", action, cb.Action, cb.Arg)
+		}
+		if want := testNow.Add(ttl).Unix(); cb.ExpiresAt.Unix() != want {
+			t.Errorf("%s: expires at %d, want %d", action, cb.ExpiresAt.Unix(), want)
+		}
+		if id, err := cb.ID(); err != nil || id != 12345 {
+			t.Errorf("%s: ID() = %d, %v", action, id, err)
+		}
+	}
+}
+
+func TestCallbackTamperedSignature(t *testing.T) {
+	data := mustEncode(t, ActionRegister, "42")
+
+	// Другой id события при старой подписи
+	forged := strings.Replace(data, ":42:", ":43:", 1)
+	if _, err := decodeCallback(forged, testNow); !errors.Is(err, ErrCallbackSignature) {
+		t.Errorf("forged arg: got %v, want ErrCallbackSignature", err)
+	}
+
+	// Испорченная подпись
+	last := data[len(data)-1]
+	flipped := byte('A')
+	if last == 'A' {
+		flipped = 'B'
+	}
+	if _, err := decodeCallback(data[:len(data)-1]+string(flipped), testNow); !errors.Is(err, ErrCallbackSignature) {
+		t.Errorf("bad signature: got %v, want ErrCallbackSignature", err)
+	}
+
+	// Подпись чужим ключом
+	saved := callbackSecret
+	callbackSecret = []byte("other-secret")
+	other := mustEncode(t, ActionRegister, "42")
+	callbackSecret = saved
+	if _, err := decodeCallback(other, testNow); !errors.Is(err, ErrCallbackSignature) {
+		t.Errorf("other key: got %v, want ErrCallbackSignature", err)
+	}
+}
+
+func TestCallbackWrongVersion(t *testing.T) {
+	data := mustEncode(t, ActionEvent, "7")
+	if _, err := decodeCallback("2"+data[1:], testNow); !errors.Is(err, ErrCallbackVersion) {
+		t.Errorf("got %v, want ErrCallbackVersion", err)
+	}
+}
+
+func TestCallbackExpired(t *testing.T) {
+	data := mustEncode(t, ActionForget, "confirm")
+	ttl := callbackTTL[ActionForget]
+
+	if _, err := decodeCallback(data, testNow.Add(ttl)); err != nil {
+		t.Errorf("at expiry: %v", err)
+	}
+	if _, err := decodeCallback(data, testNow.Add(ttl+time.Second)); !errors.Is(err, ErrCallbackExpired) {
+		t.Errorf("after expiry: got %v, want ErrCallbackExpired", err)
+	}
+}
+
+func TestCallbackMalformed(t *testing.T) {
+	valid := mustEncode(t, ActionCancel, "5")
+	parts := strings.Split(valid, ":")
+
+	// Неизвестное действие с правильной подписью
+	payload := strings.Join([]string{callbackVersion, "zzz", "5", parts[3]}, ":")
+	unknown := payload + ":" + signCallback(payload)
+
+	// Срок годности не число в base36
+	payload = strings.Join([]string{callbackVersion, string(ActionCancel), "5", "!!"}, ":")
+	badExp := payload + ":" + signCallback(payload)
+
+	for name, data := range map[string]string{
+		"empty":          "",
+		"legacy":         "register_5",
+		"too few parts":  strings.Join(parts[:4], ":"),
+		"too many parts": valid + ":x",
+		"unknown action": unknown,
+		"bad expiry":     badExp,
+	} {
+		if _, err := decodeCallback(data, testNow); !errors.Is(err, ErrCallbackMalformed) {
+			t.Errorf("%s: got %v, want ErrCallbackMalformed", name, err)
+		}
+	}
+
+	for _, arg := range []string{"", "abc", "-1", "0"} {
+		if _, err := (Callback{Arg: arg}).ID(); !errors.Is(err, ErrCallbackMalformed) {
+			t.Errorf("ID(%q): got %v, want ErrCallbackMalformed", arg, err)
+		}
+	}
+}
+
+func TestCallbackColonInArg(t *testing.T) {
+	data, err := encodeCallback(ActionGuest, "del:1", testNow)
+	if !errors.Is(err, ErrCallbackSeparator) {
+		t.Errorf("got %v, want ErrCallbackSeparator", err)
+	}
+	if data != "" {
+		t.Errorf("got data %q, want empty", data)
+	}
+}
+
+func TestCallbackSizeLimit(t *testing.T) {
+	// Версия, действие, срок и подпись занимают около 25 байт
+	fits := strings.Repeat("9", 30)
+	if _, err := encodeCallback(ActionRegister, fits, testNow); err != nil {
+		t.Errorf("30-byte arg: %v", err)
+	}
+
+	long := strings.Repeat("9", callbackMaxBytes)
+	data, err := encodeCallback(ActionRegister, long, testNow)
+	if !errors.Is(err, ErrCallbackTooLong) {
+		t.Errorf("64-byte arg: got %v, want ErrCallbackTooLong", err)
+	}
+	if data != "" {
+		t.Errorf("64-byte arg: got data %q, want empty", data)
+	}
+}
@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"log"
+	"runtime/debug"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultWorkers  = 8
+	workerQueueSize = 64
+	dedupWindowSize = 1024
+)
+
+// Dispatcher раздаёт обновления по пулу воркеров так, что обновления одного чата
+// обрабатываются строго по очереди, а разных чатов — параллельно.
+// Очереди ограничены: если воркер не успевает, Dispatch блокируется, и бот
+// перестаёт забирать новые обновления у Telegram, пока очередь не разгрузится.
+type Dispatcher struct {
+	bot    *tgbotapi.BotAPI
+	queues []chan tgbotapi.Update
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	seen     map[int]struct{}
+	seenList []int
+}
+
+func NewDispatcher(bot *tgbotapi.BotAPI, workers int) *Dispatcher {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+
+	d := &Dispatcher{
+		bot:    bot,
+		queues: make([]chan tgbotapi.Update, workers),
+		seen:   make(map[int]struct{}, dedupWindowSize),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, workerQueueSize)
+		d.wg.Add(1)
+		go d.worker(d.queues[i])
+	}
+	return d
+}
+
+// Поставить обновление в очередь его чата. Повторно доставленные обновления пропускаются
+func (d *Dispatcher) Dispatch(update tgbotapi.Update) {
+	if d.isDuplicate(update.UpdateID) {
+		log.Printf("Dispatcher: skip duplicate update %d\n", update.UpdateID)
+		return
+	}
+
+	shard := uint64(updateKey(update)) % uint64(len(d.queues))
+	d.queues[shard] <- update
+}
+
+// Остановить приём обновлений и дождаться обработки уже поставленных в очередь
+func (d *Dispatcher) Stop() {
+	for _, q := range d.queues {
+		close(q)
+	}
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) worker(queue <-chan tgbotapi.Update) {
+	defer d.wg.Done()
+	for update := range queue {
+		d.handle(update)
+	}
+}
+
+func (d *Dispatcher) handle(update tgbotapi.Update) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Dispatcher: panic while handling update %d: %v\n%s", update.UpdateID, r, debug.Stack())
+		}
+	}()
+	HandleUpdate(d.bot, update)
+}
+
+func (d *Dispatcher) isDuplicate(updateID int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.seen[updateID]; ok {
+		return true
+	}
+	d.seen[updateID] = struct{}{}
+	d.seenList = append(d.seenList, updateID)
+	if len(d.seenList) > dedupWindowSize {
+		delete(d.seen, d.seenList[0])
+		d.seenList = d.seenList[1:]
+	}
+	return false
+}
+
+// Ключ очереди: чат, из которого пришло обновление, или пользователь, если чата нет (inline-режим)
+func updateKey(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.MyChatMember != nil:
+		return update.MyChatMember.Chat.ID
+	}
+	if from := update.SentFrom(); from != nil {
+		return from.ID
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+	"laverdad-bot/sender"
+	"laverdad-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Запись прямо из группы клуба: под анонсом недели по кнопке на каждую игру.
+// Кнопка записывает игрока, если он уже зарегистрирован в боте, а иначе открывает бот
+// по ссылке на событие. Ответы отправляются в личку, а в анонсе обновляется число записавшихся
+
+// Сколько ждать перед правкой анонса: серия записей подряд даёт одну правку,
+// а Telegram разрешает боту не больше 20 сообщений в минуту в группу
+const groupRefreshDelay = 10 * time.Second
+
+var (
+	groupRefreshes   = map[int64]*time.Timer{}
+	groupRefreshesMu sync.Mutex
+)
+
+func init() {
+	services.AnnouncementMarkup = announcementMarkup
+}
+
+func announcementMarkup(events []db.Event) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, e := range events {
+		count, err := db.GetEventParticipantsCount(e.ID)
+		if err != nil {
+			log.Println(err)
+		}
+		// Кнопки в группе общие для всех участников, поэтому дата — на языке клуба
+		label := fmt.Sprintf("📝 %s %s · 👥 %d", locales.FormatDateShort(locales.DefaultLang, e.StartsAt), e.StartsAt.Format("15:04"), count)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(callbackButton(label, ActionRegister, e.ID)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Обновить число записавшихся в анонсах, где есть событие
+func refreshGroupAnnouncements(bot *tgbotapi.BotAPI, eventID int) {
+	for _, a := range db.GetGroupAnnouncementsForEvent(eventID) {
+		scheduleGroupRefresh(bot, a)
+	}
+}
+
+func scheduleGroupRefresh(bot *tgbotapi.BotAPI, a db.GroupAnnouncement) {
+	groupRefreshesMu.Lock()
+	defer groupRefreshesMu.Unlock()
+
+	if _, ok := groupRefreshes[a.ID]; ok {
+		return
+	}
+	groupRefreshes[a.ID] = time.AfterFunc(groupRefreshDelay, func() {
+		groupRefreshesMu.Lock()
+		delete(groupRefreshes, a.ID)
+		groupRefreshesMu.Unlock()
+
+		editGroupAnnouncement(bot, a)
+	})
+}
+
+func editGroupAnnouncement(bot *tgbotapi.BotAPI, a db.GroupAnnouncement) {
+	var events []db.Event
+	for _, id := range a.EventIDs {
+		e, err := db.FetchEvent(id)
+		if err != nil {
+			// Событие удалено — его кнопка из анонса пропадает
+			continue
+		}
+		events = append(events, e)
+	}
+
+	edit := render.Edit(a.ChatID, a.MessageID, services.AnnouncementText(bot, events))
+	markup := announcementMarkup(events)
+	edit.ReplyMarkup = &markup
+	sender.Enqueue(a.ChatID, edit, func(err error) {
+		if err != nil {
+			log.Printf("editGroupAnnouncement %d error: %v\n", a.ID, err)
+		}
+	})
+}
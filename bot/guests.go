@@ -0,0 +1,251 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"laverdad-bot/db"
+	googleapi "laverdad-bot/google-api"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Событие, на которое игрок записывает гостя, пока вводит его имя
+var (
+	pendingGuests   = map[int64]int{}
+	pendingGuestsMu sync.Mutex
+)
+
+func setPendingGuest(chatID int64, eventID int) {
+	pendingGuestsMu.Lock()
+	defer pendingGuestsMu.Unlock()
+	pendingGuests[chatID] = eventID
+}
+
+func takePendingGuest(chatID int64) int {
+	pendingGuestsMu.Lock()
+	defer pendingGuestsMu.Unlock()
+	eventID := pendingGuests[chatID]
+	delete(pendingGuests, chatID)
+	return eventID
+}
+
+// Запись игрока в /my: гости и кнопки «отменить», «передать место», «+ гость», «убрать гостя»
+func myRegistration(r db.Registration, tgID int64, lang locales.Lang) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := render.T(lang, "my.item", r.Title, r.StartsAt.Format("02.01.2006 15:04"))
+	guests := db.GetGuests(tgID, r.ID)
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	first := tgbotapi.NewInlineKeyboardRow(callbackButton(locales.T(lang, "my.cancel"), ActionCancel, r.ID))
+	if r.StartsAt.After(time.Now()) {
+		first = append(first, callbackButton(locales.T(lang, "my.transfer"), ActionTransfer, fmt.Sprintf("ask-%d", r.ID)))
+		if len(guests) < db.MaxGuestsPerPlayer {
+			first = append(first, callbackButton(locales.T(lang, "my.add_guest"), ActionGuest, fmt.Sprintf("add-%d", r.ID)))
+		}
+	}
+	rows = append(rows, first)
+
+	if len(guests) > 0 {
+		names := make([]string, len(guests))
+		var row []tgbotapi.InlineKeyboardButton
+		for i, g := range guests {
+			names[i] = g.Name
+			row = append(row, callbackButton(locales.T(lang, "my.remove_guest", g.Name), ActionGuest, fmt.Sprintf("del-%d", g.RegistrationID)))
+		}
+		text += render.T(lang, "my.guests", strings.Join(names, ", "))
+		rows = append(rows, row)
+	}
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// Кнопки гостей в /my: add-<id события> или del-<id регистрации гостя>
+func handleGuestCallback(bot *tgbotapi.BotAPI, chatID int64, tgID int64, mesgID int, arg string, lang locales.Lang) {
+	action, idStr, _ := strings.Cut(arg, "-")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case "add":
+		if len(db.GetGuests(tgID, id)) >= db.MaxGuestsPerPlayer {
+			sendText(bot, chatID, render.T(lang, "guest.limit", db.MaxGuestsPerPlayer))
+			return
+		}
+		setPendingGuest(chatID, id)
+		setUserState(chatID, StateGuestName)
+		sendText(bot, chatID, render.T(lang, "guest.ask_name"))
+
+	case "del":
+		line, _ := db.GetRegistrationLineByID(id)
+		eventID, err := db.RemoveGuest(tgID, id)
+		if err != nil {
+			log.Println("RemoveGuest error:", err)
+			sendText(bot, chatID, render.T(lang, "guest.remove_failed"))
+			return
+		}
+		if event, err := db.FetchEvent(int64(eventID)); err == nil {
+			sheetName := googleapi.SheetName(event.Title, event.StartsAt)
+			googleapi.Async(func() { googleapi.UpdateRegistrationStateToSheet(id, sheetName, time.Now()) })
+		}
+		sendText(bot, chatID, render.T(lang, "guest.removed", line.Name))
+		refreshGroupAnnouncements(bot, eventID)
+		refreshMyRegistration(bot, chatID, tgID, mesgID, eventID, lang)
+	}
+}
+
+// Ввод имени гостя. Возвращает true, если сообщение обработано
+func handleGuestInput(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state State, lang locales.Lang) bool {
+	if state != StateGuestName {
+		return false
+	}
+	chatID := msg.Chat.ID
+	tgID := msg.From.ID
+
+	if msg.IsCommand() {
+		takePendingGuest(chatID)
+		return leaveInput(bot, msg, lang)
+	}
+
+	name, err := validateName(msg.Text, lang)
+	if err != nil {
+		sendText(bot, chatID, render.T(lang, "guest.retry_name", err))
+		return true
+	}
+	setUserState(chatID, StateNone)
+	eventID := takePendingGuest(chatID)
+	if eventID == 0 {
+		return true
+	}
+
+	event, err := db.FetchEvent(int64(eventID))
+	if err != nil {
+		sendText(bot, chatID, render.T(lang, "event.load_failed"))
+		return true
+	}
+	if !event.StartsAt.After(time.Now()) {
+		sendText(bot, chatID, render.T(lang, "register.closed"))
+		return true
+	}
+
+	regID, err := db.AddGuest(tgID, eventID, name)
+	if errors.Is(err, db.ErrTooManyGuests) {
+		sendText(bot, chatID, render.T(lang, "guest.limit", db.MaxGuestsPerPlayer))
+		return true
+	}
+	if err != nil {
+		log.Println("AddGuest error:", err)
+		sendText(bot, chatID, render.T(lang, "guest.failed"))
+		return true
+	}
+
+	line, _ := db.GetRegistrationLineByID(regID)
+	addRegistrationToSheet(event, line)
+	sendText(bot, chatID, render.T(lang, "guest.added", name))
+	refreshGroupAnnouncements(bot, eventID)
+	return true
+}
+
+// Обновить сообщение /my после изменения списка гостей
+func refreshMyRegistration(bot *tgbotapi.BotAPI, chatID int64, tgID int64, mesgID int, eventID int, lang locales.Lang) {
+	for _, r := range db.GetUserRegistrations(tgID) {
+		if r.ID != eventID {
+			continue
+		}
+		text, markup := myRegistration(r, tgID, lang)
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, mesgID, text, markup)
+		edit.ParseMode = render.ParseMode
+		if _, err := bot.Send(edit); err != nil {
+			log.Println("refreshMyRegistration error:", err)
+		}
+		return
+	}
+}
+
+// /register <id события> <@username|ник|telegram_id>
+func handleAdminRegister(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) != 2 {
+		sendText(bot, msg.Chat.ID, "Формат: <code>/register id_события @username</code>\nМожно указать ник или telegram_id. Человека без Telegram записывает <code>/register_guest</code>")
+		return
+	}
+	event, ok := adminEventArg(bot, msg.Chat.ID, args[0])
+	if !ok {
+		return
+	}
+	user, err := db.FindUser(args[1])
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	if db.RegistrationExists(int64(event.ID), int64(user.ID)) {
+		sendText(bot, msg.Chat.ID, "Игрок уже записан на это событие.")
+		return
+	}
+
+	if err := db.RegisterUserByAdmin(user.TelegramID, event.ID, msg.From.ID); err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	line, _ := db.GetRegistrationLine(int(user.TelegramID), event.ID)
+	addRegistrationToSheet(event, line)
+	audit(msg.From.ID, "register", strconv.FormatInt(user.TelegramID, 10), nil, event.ID)
+
+	lang := locales.ParseLang(user.Language)
+	sendText(bot, user.ChatID, render.T(lang, "register.by_admin", event.Title, event.StartsAt.Format("02.01.2006 15:04")))
+	sendText(bot, msg.Chat.ID, fmt.Sprintf("✅ %s записан на #%d %s", render.Escape(user.Name), event.ID, render.Escape(event.Title)))
+	refreshGroupAnnouncements(bot, event.ID)
+}
+
+// /register_guest <id события> <имя> — человек без Telegram
+func handleAdminRegisterGuest(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	idStr, name, _ := strings.Cut(strings.TrimSpace(msg.CommandArguments()), " ")
+	if idStr == "" || strings.TrimSpace(name) == "" {
+		sendText(bot, msg.Chat.ID, "Формат: <code>/register_guest id_события Имя</code>")
+		return
+	}
+	event, ok := adminEventArg(bot, msg.Chat.ID, idStr)
+	if !ok {
+		return
+	}
+	name, err := validateName(name, locales.RU)
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+
+	regID, err := db.AddWalkIn(event.ID, name, msg.From.ID)
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	line, _ := db.GetRegistrationLineByID(regID)
+	addRegistrationToSheet(event, line)
+	// Имя гостя в журнал не попадает: журнал не чистится, а имя есть в самой регистрации
+	audit(msg.From.ID, "register_guest", strconv.Itoa(regID), nil, event.ID)
+
+	sendText(bot, msg.Chat.ID, fmt.Sprintf("✅ %s записан на #%d %s", render.Escape(name), event.ID, render.Escape(event.Title)))
+	refreshGroupAnnouncements(bot, event.ID)
+}
+
+// Событие из аргумента административной команды; о проблеме сообщает сам
+func adminEventArg(bot *tgbotapi.BotAPI, chatID int64, arg string) (db.Event, bool) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		sendText(bot, chatID, "id события должен быть числом.")
+		return db.Event{}, false
+	}
+	event, err := db.FetchEvent(int64(id))
+	if err != nil {
+		sendText(bot, chatID, "Событие не найдено.")
+		return db.Event{}, false
+	}
+	return event, true
+}
@@ -0,0 +1,99 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Inline-режим: «@бот пятница» в любом чате показывает ближайшие игры,
+// а карточку игры с кнопкой записи можно отправить собеседнику.
+// Режим включается у @BotFather командой /setinline, а ChosenInlineResult приходит после /setinlinefeedback
+
+const (
+	inlineResultPrefix = "ev_"
+	inlineCacheSeconds = 60
+)
+
+func handleInlineQuery(bot *tgbotapi.BotAPI, query *tgbotapi.InlineQuery) {
+	lang := userLang(query.From)
+	filter := strings.ToLower(strings.TrimSpace(query.Query))
+
+	var results []interface{}
+	for _, e := range db.GetEvents() {
+		if filter != "" && !eventMatches(e, filter) {
+			continue
+		}
+
+		article := render.Article(
+			fmt.Sprintf("%s%d", inlineResultPrefix, e.ID),
+			e.Title,
+			e.DescriptionIn(lang),
+		)
+		article.Description = fmt.Sprintf("%s, %s", locales.FormatDate(lang, e.StartsAt), e.StartsAt.Format("15:04"))
+		markup := tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(callbackButton(locales.T(lang, "event.register"), ActionRegister, e.ID)),
+			tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(locales.T(lang, "inline.open_bot"), eventLink(bot, e.ID))),
+		)
+		article.ReplyMarkup = &markup
+		results = append(results, article)
+	}
+
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     inlineCacheSeconds,
+		// Ответ зависит от языка пользователя
+		IsPersonal: true,
+	}
+	if _, err := bot.Request(answer); err != nil {
+		log.Println("handleInlineQuery error:", err)
+	}
+}
+
+// Подходит ли событие под текст запроса: ищем в названии и дате на всех языках,
+// чтобы «friday», «viernes» и «пятница» находили одно и то же
+func eventMatches(e db.Event, filter string) bool {
+	haystack := []string{e.Title, e.StartsAt.Format("02.01")}
+	for _, lang := range locales.Langs {
+		haystack = append(haystack, locales.FormatDate(lang, e.StartsAt), locales.FormatDateShort(lang, e.StartsAt))
+	}
+	return strings.Contains(strings.ToLower(strings.Join(haystack, " ")), filter)
+}
+
+func handleChosenInlineResult(result *tgbotapi.ChosenInlineResult) {
+	eventID, err := strconv.Atoi(strings.TrimPrefix(result.ResultID, inlineResultPrefix))
+	if err != nil {
+		return
+	}
+	log.Printf("User %d shared event %d via inline mode\n", result.From.ID, eventID)
+}
+
+// Нажатие кнопки под inline-сообщением или анонсом в группе. Сообщение видят все участники чата,
+// поэтому его не редактируем, а подтверждение отправляем игроку в личку
+func handleSharedCallback(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery, cb Callback, lang locales.Lang) {
+	eventID, err := cb.ID()
+	if cb.Action != ActionRegister || err != nil {
+		bot.Request(tgbotapi.NewCallback(callback.ID, locales.T(lang, "callback.expired")))
+		return
+	}
+
+	user := db.GetUser(callback.From.ID)
+	if user.ID == 0 || user.Name == "" || user.Nickname == "" || user.ChatID == 0 {
+		// Игрок ещё не зарегистрирован в боте — открываем бот по ссылке на событие
+		answer := tgbotapi.NewCallback(callback.ID, "")
+		answer.URL = eventLink(bot, eventID)
+		bot.Request(answer)
+		return
+	}
+
+	toast := registerForEvent(bot, user.ChatID, user.TelegramID, eventID, lang)
+	bot.Request(tgbotapi.NewCallback(callback.ID, toast))
+}
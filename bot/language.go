@@ -0,0 +1,47 @@
+package bot
+
+import (
+	"log"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Язык пользователя: выбранный в боте, а если пользователя ещё нет в базе — по language_code из Telegram
+func userLang(from *tgbotapi.User) locales.Lang {
+	if from == nil {
+		return locales.DefaultLang
+	}
+	if user := db.GetUser(from.ID); user.Language != "" {
+		return locales.ParseLang(user.Language)
+	}
+	return locales.ParseLang(from.LanguageCode)
+}
+
+// /language — выбор языка
+func showLanguages(bot *tgbotapi.BotAPI, chatID int64, lang locales.Lang) {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, l := range locales.Langs {
+		row = append(row, callbackButton(locales.LangNames[l], ActionLanguage, string(l)))
+	}
+	msg := render.Message(chatID, render.T(lang, "language.choose"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("showLanguages error:", err)
+	}
+}
+
+func handleLanguageCallback(bot *tgbotapi.BotAPI, chatID int64, tgID int64, mesgID int, arg string) {
+	lang := locales.Lang(arg)
+	if _, ok := locales.LangNames[lang]; !ok {
+		return
+	}
+	if err := db.UpdateUserLanguage(tgID, lang); err != nil {
+		log.Println("UpdateUserLanguage error:", err)
+		return
+	}
+	bot.Send(render.Edit(chatID, mesgID, render.T(lang, "language.saved")))
+}
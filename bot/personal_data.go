@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"laverdad-bot/db"
+	googleapi "laverdad-bot/google-api"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// /mydata — отправить пользователю JSON со всеми данными о нём
+func handleMyData(bot *tgbotapi.BotAPI, chatID int64, tgID int64, lang locales.Lang) {
+	data, err := db.GetPersonalData(tgID)
+	if err != nil {
+		log.Println("handleMyData error:", err)
+		sendText(bot, chatID, render.T(lang, "mydata.failed"))
+		return
+	}
+
+	body, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		log.Println("handleMyData marshal error:", err)
+		sendText(bot, chatID, render.T(lang, "mydata.file_failed"))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("laverdad-data-%s.json", locales.Now().Format("2006-01-02")),
+		Bytes: body,
+	})
+	doc.Caption = render.T(lang, "mydata.caption")
+	doc.ParseMode = render.ParseMode
+	if _, err := bot.Send(doc); err != nil {
+		log.Println("handleMyData send error:", err)
+	}
+}
+
+// /forget_me — запросить подтверждение удаления персональных данных
+func handleForgetMe(bot *tgbotapi.BotAPI, chatID int64, lang locales.Lang) {
+	btn := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton(locales.T(lang, "forget.yes"), ActionForget, "confirm"),
+			callbackButton(locales.T(lang, "common.cancel"), ActionForget, "cancel"),
+		),
+	)
+	msg := render.Message(chatID, render.T(lang, "forget.confirm"))
+	msg.ReplyMarkup = btn
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("handleForgetMe error:", err)
+	}
+}
+
+func handleForgetCallback(bot *tgbotapi.BotAPI, chatID int64, tgID int64, mesgID int, confirmed bool, lang locales.Lang) {
+	if !confirmed {
+		bot.Send(render.Edit(chatID, mesgID, render.T(lang, "forget.cancelled")))
+		return
+	}
+
+	// Строки таблицы ищем по данным, которые нужно собрать до обезличивания,
+	// пока пользователь ещё находится по telegram_id
+	rows := googleapi.ForgottenRows{TelegramLink: db.TelegramLink(tgID), GuestIDs: map[int]bool{}}
+	if nickname := db.GetUser(tgID).Nickname; nickname != "" {
+		rows.GuestMark = googleapi.GuestMark(nickname)
+	}
+	for _, id := range db.GetHostedGuestIDs(tgID) {
+		rows.GuestIDs[id] = true
+	}
+
+	if err := db.ForgetUser(tgID); err != nil {
+		log.Println("ForgetUser error:", err)
+		sendText(bot, chatID, render.T(lang, "forget.failed"))
+		return
+	}
+	setUserState(chatID, StateNone)
+
+	googleapi.Async(func() {
+		if err := googleapi.ForgetUserInSheets(rows, time.Now()); err != nil {
+			log.Println(err)
+		}
+	})
+
+	bot.Send(render.Edit(chatID, mesgID, render.T(lang, "forget.done")))
+}
@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"log"
+	"time"
+
+	"laverdad-bot/db"
+	googleapi "laverdad-bot/google-api"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func showProfile(bot *tgbotapi.BotAPI, chatID int64, tgID int64) {
+	user := db.GetUser(tgID)
+	lang := locales.ParseLang(user.Language)
+
+	phone := user.Phone
+	if phone == "" {
+		phone = locales.T(lang, "profile.phone_none")
+	}
+	text := render.T(lang, "profile.text", user.Name, user.Nickname, phone)
+
+	btn := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			callbackButton(locales.T(lang, "profile.btn_name"), ActionProfile, "name"),
+			callbackButton(locales.T(lang, "profile.btn_nickname"), ActionProfile, "nickname"),
+			callbackButton(locales.T(lang, "profile.btn_phone"), ActionProfile, "phone"),
+		),
+	)
+	msg := render.Message(chatID, text)
+	msg.ReplyMarkup = btn
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("showProfile error:", err)
+	}
+}
+
+func handleProfileCallback(bot *tgbotapi.BotAPI, chatID int64, field string, lang locales.Lang) {
+	switch field {
+	case "name":
+		setUserState(chatID, StateEditName)
+		sendText(bot, chatID, render.T(lang, "profile.ask_name"))
+	case "nickname":
+		setUserState(chatID, StateEditNickname)
+		sendText(bot, chatID, render.T(lang, "profile.ask_nickname"))
+	case "phone":
+		setUserState(chatID, StateEditPhone)
+		keyboard := tgbotapi.NewReplyKeyboard(
+			tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButtonContact(locales.T(lang, "profile.share_phone"))),
+			tgbotapi.NewKeyboardButtonRow(tgbotapi.NewKeyboardButton(locales.T(lang, "common.cancel"))),
+		)
+		keyboard.OneTimeKeyboard = true
+		msg := render.Message(chatID, render.T(lang, "profile.ask_phone"))
+		msg.ReplyMarkup = keyboard
+		if _, err := bot.Send(msg); err != nil {
+			log.Println("handleProfileCallback error:", err)
+		}
+	}
+}
+
+// Команда во время ввода текста: состояние сбрасывается, /cancel на этом и заканчивается,
+// а любая другая команда выполняется как обычно. Возвращает true, если сообщение обработано
+func leaveInput(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, lang locales.Lang) bool {
+	setUserState(msg.Chat.ID, StateNone)
+	if msg.Command() != "cancel" {
+		return false
+	}
+	sendText(bot, msg.Chat.ID, render.T(lang, "input.cancelled"))
+	return true
+}
+
+// Обработка ввода при редактировании профиля. Возвращает true, если сообщение обработано
+func handleProfileInput(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state State, lang locales.Lang) bool {
+	chatID := msg.Chat.ID
+	tgID := msg.From.ID
+
+	switch state {
+	case StateEditName, StateEditNickname, StateEditPhone:
+		if msg.IsCommand() {
+			if state == StateEditPhone && msg.Command() == "cancel" {
+				// Как кнопка «Отмена»: заодно убираем клавиатуру с кнопкой телефона
+				setUserState(chatID, StateNone)
+				removeKeyboard(bot, chatID, render.T(lang, "profile.phone_cancelled"))
+				return true
+			}
+			return leaveInput(bot, msg, lang)
+		}
+	}
+
+	switch state {
+	case StateEditName:
+		name, err := validateName(msg.Text, lang)
+		if err != nil {
+			sendText(bot, chatID, render.T(lang, "profile.retry_name", err))
+			return true
+		}
+		if err := db.UpdateUserName(tgID, name); err != nil {
+			log.Println("UpdateUserName error:", err)
+			sendText(bot, chatID, render.T(lang, "profile.name_save_failed"))
+			return true
+		}
+	case StateEditNickname:
+		nickname, err := validateNickname(msg.Text, tgID, lang)
+		if err != nil {
+			sendText(bot, chatID, render.T(lang, "profile.retry_nickname", err))
+			return true
+		}
+		if err := db.UpdateUserNickname(tgID, nickname); err != nil {
+			log.Println("UpdateUserNickname error:", err)
+			sendText(bot, chatID, render.T(lang, "profile.nickname_save_failed"))
+			return true
+		}
+	case StateEditPhone:
+		if msg.Contact == nil {
+			if msg.Text == locales.T(lang, "common.cancel") {
+				setUserState(chatID, StateNone)
+				removeKeyboard(bot, chatID, render.T(lang, "profile.phone_cancelled"))
+				return true
+			}
+			sendText(bot, chatID, render.T(lang, "profile.use_phone_button"))
+			return true
+		}
+		if msg.Contact.UserID != tgID {
+			sendText(bot, chatID, render.T(lang, "profile.own_phone_only"))
+			return true
+		}
+		if err := db.UpdateUserPhone(tgID, msg.Contact.PhoneNumber); err != nil {
+			log.Println("UpdateUserPhone error:", err)
+			sendText(bot, chatID, render.T(lang, "profile.phone_save_failed"))
+			return true
+		}
+		setUserState(chatID, StateNone)
+		removeKeyboard(bot, chatID, render.T(lang, "profile.phone_saved"))
+		showProfile(bot, chatID, tgID)
+		return true
+	default:
+		return false
+	}
+
+	setUserState(chatID, StateNone)
+	googleapi.Async(func() { syncUserToSheets(tgID) })
+	sendText(bot, chatID, render.T(lang, "profile.updated"))
+	showProfile(bot, chatID, tgID)
+	return true
+}
+
+// Обновить данные игрока во всех листах предстоящих событий, на которые он записан
+func syncUserToSheets(tgID int64) {
+	user := db.GetUser(tgID)
+	for _, r := range db.GetUserUpcomingRegistrations(tgID) {
+		googleapi.UpdateRegistrationUserToSheet(r.ID, googleapi.SheetName(r.Title, r.StartsAt), user.UserName, user.Name, user.Nickname, time.Now())
+	}
+}
+
+func removeKeyboard(bot *tgbotapi.BotAPI, chatID int64, text string) {
+	msg := render.Message(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("removeKeyboard error:", err)
+	}
+}
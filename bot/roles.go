@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"laverdad-bot/db"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleAdmin     Role = "admin"
+	RoleHost      Role = "host"
+	RoleTreasurer Role = "treasurer"
+)
+
+type Permission string
+
+const (
+	PermManageEvents      Permission = "manage_events"
+	PermNotify            Permission = "notify"
+	PermViewRegistrations Permission = "view_registrations"
+	PermManageMemberships Permission = "manage_memberships"
+	PermViewReports       Permission = "view_reports"
+	PermModerateUsers     Permission = "moderate_users"
+	PermManageRoles       Permission = "manage_roles"
+	PermViewAudit         Permission = "view_audit"
+	PermManageTemplates   Permission = "manage_templates"
+	PermRegisterOthers    Permission = "register_others"
+)
+
+var roleTitles = map[Role]string{
+	RoleOwner:     "владелец",
+	RoleAdmin:     "администратор",
+	RoleHost:      "ведущий/судья",
+	RoleTreasurer: "казначей",
+}
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermManageEvents, PermNotify, PermViewRegistrations, PermManageMemberships,
+		PermViewReports, PermModerateUsers, PermManageRoles, PermViewAudit, PermManageTemplates,
+		PermRegisterOthers,
+	},
+	RoleAdmin: {
+		PermManageEvents, PermNotify, PermViewRegistrations, PermManageMemberships,
+		PermViewReports, PermModerateUsers, PermViewAudit, PermManageTemplates, PermRegisterOthers,
+	},
+	RoleHost:      {PermViewRegistrations, PermRegisterOthers},
+	RoleTreasurer: {PermViewRegistrations, PermManageMemberships, PermViewReports},
+}
+
+// Административные команды в порядке вывода в справке
+var adminCommands = []struct {
+	command    string
+	permission Permission
+}{
+	{"addevent", PermManageEvents},
+	{"generate", PermManageEvents},
+	{"event_text", PermManageEvents},
+	{"invite_code", PermManageEvents},
+	{"registrations", PermViewRegistrations},
+	{"register", PermRegisterOthers},
+	{"register_guest", PermRegisterOthers},
+	{"notify_registration", PermNotify},
+	{"broadcast", PermNotify},
+	{"templates", PermManageTemplates},
+	{"template", PermManageTemplates},
+	{"template_preview", PermManageTemplates},
+	{"template_set", PermManageTemplates},
+	{"template_reset", PermManageTemplates},
+	{"membership", PermManageMemberships},
+	{"extend_membership", PermManageMemberships},
+	{"memberships", PermManageMemberships},
+	{"report", PermViewReports},
+	{"nick_duplicates", PermModerateUsers},
+	{"roles", PermManageRoles},
+	{"grant", PermManageRoles},
+	{"revoke", PermManageRoles},
+	{"audit", PermViewAudit},
+	{"audit_export", PermViewAudit},
+}
+
+func commandPermission(command string) (Permission, bool) {
+	for _, c := range adminCommands {
+		if c.command == command {
+			return c.permission, true
+		}
+	}
+	return "", false
+}
+
+// IsAdmin сообщает, есть ли у пользователя хоть одна административная роль
+func IsAdmin(userID int64) bool {
+	return len(db.GetUserRoles(userID)) > 0
+}
+
+func HasPermission(userID int64, perm Permission) bool {
+	for _, role := range db.GetUserRoles(userID) {
+		for _, p := range rolePermissions[Role(role)] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func adminHelp(userID int64) string {
+	text := "Доступные команды:"
+	for _, c := range adminCommands {
+		if HasPermission(userID, c.permission) {
+			text += "\n/" + c.command
+		}
+	}
+	return text
+}
+
+func parseRole(s string) (Role, bool) {
+	role := Role(strings.ToLower(s))
+	_, ok := rolePermissions[role]
+	return role, ok
+}
+
+// Найти telegram_id по аргументу команды: число или @username/ник зарегистрированного пользователя
+func resolveTelegramID(arg string) (int64, error) {
+	if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		return id, nil
+	}
+	user, err := db.FindUser(arg)
+	if err != nil {
+		return 0, err
+	}
+	return user.TelegramID, nil
+}
+
+func roleList() string {
+	roles := make([]string, 0, len(roleTitles))
+	for _, r := range []Role{RoleOwner, RoleAdmin, RoleHost, RoleTreasurer} {
+		roles = append(roles, fmt.Sprintf("%s (%s)", r, roleTitles[r]))
+	}
+	return strings.Join(roles, ", ")
+}
+
+// /grant <@username|ник|telegram_id> <роль>
+func handleGrantRole(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) != 2 {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Формат: /grant @username роль\nРоли: "+roleList()))
+		return
+	}
+	role, ok := parseRole(args[1])
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Неизвестная роль. Роли: "+roleList()))
+		return
+	}
+	tgID, err := resolveTelegramID(args[0])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+
+	if err := db.GrantRole(tgID, string(role), msg.From.ID); err != nil {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	audit(msg.From.ID, "role_grant", strconv.FormatInt(tgID, 10), nil, map[string]string{"role": string(role)})
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("✅ Роль «%s» выдана пользователю %d", roleTitles[role], tgID)))
+}
+
+// /revoke <@username|ник|telegram_id> <роль>
+func handleRevokeRole(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	args := strings.Fields(msg.CommandArguments())
+	if len(args) != 2 {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Формат: /revoke @username роль\nРоли: "+roleList()))
+		return
+	}
+	role, ok := parseRole(args[1])
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Неизвестная роль. Роли: "+roleList()))
+		return
+	}
+	tgID, err := resolveTelegramID(args[0])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+
+	// Нельзя остаться без владельцев
+	if role == RoleOwner && db.CountRoleHolders(string(RoleOwner)) <= 1 {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Нельзя отозвать роль у последнего владельца."))
+		return
+	}
+
+	revoked, err := db.RevokeRole(tgID, string(role))
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	if !revoked {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "У пользователя нет такой роли."))
+		return
+	}
+	audit(msg.From.ID, "role_revoke", strconv.FormatInt(tgID, 10), map[string]string{"role": string(role)}, nil)
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("✅ Роль «%s» отозвана у пользователя %d", roleTitles[role], tgID)))
+}
+
+func handleListRoles(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	roles := db.GetAllRoles()
+	if len(roles) == 0 {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Роли ещё не выданы."))
+		return
+	}
+
+	text := "Роли:\n"
+	for _, r := range roles {
+		text += fmt.Sprintf("- %s: %s (%s), telegram_id=%d\n", roleTitles[Role(r.Role)], r.Name, r.Nickname, r.TelegramID)
+	}
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, text))
+}
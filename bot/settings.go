@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"database/sql"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var quietHoursRe = regexp.MustCompile(`^(\d{1,2})\s*-\s*(\d{1,2})$`)
+
+func settingsText(s db.UserSettings, lang locales.Lang) string {
+	quiet := locales.T(lang, "settings.quiet_off")
+	if s.QuietFrom.Valid && s.QuietTo.Valid {
+		quiet = locales.T(lang, "settings.quiet_range", s.QuietFrom.Int16, s.QuietTo.Int16)
+	}
+	return render.T(lang, "settings.text", onOff(s.Reminder24), onOff(s.Reminder3), onOff(s.Reminder1), onOff(s.NewWeekDM), quiet)
+}
+
+func settingsMarkup(s db.UserSettings, lang locales.Lang) tgbotapi.InlineKeyboardMarkup {
+	toggle := func(title string, on bool, arg string) tgbotapi.InlineKeyboardButton {
+		return callbackButton(onOff(on)+" "+title, ActionSettings, arg)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			toggle(locales.T(lang, "settings.btn_24"), s.Reminder24, "r24"),
+			toggle(locales.T(lang, "settings.btn_3"), s.Reminder3, "r3"),
+			toggle(locales.T(lang, "settings.btn_1"), s.Reminder1, "r1"),
+		),
+		tgbotapi.NewInlineKeyboardRow(callbackButton(locales.T(lang, "settings.btn_none"), ActionSettings, "none")),
+		tgbotapi.NewInlineKeyboardRow(toggle(locales.T(lang, "settings.btn_week"), s.NewWeekDM, "week")),
+		tgbotapi.NewInlineKeyboardRow(callbackButton(locales.T(lang, "settings.btn_quiet"), ActionSettings, "quiet")),
+	)
+}
+
+func onOff(on bool) string {
+	if on {
+		return "✅"
+	}
+	return "❌"
+}
+
+func showSettings(bot *tgbotapi.BotAPI, chatID int64, tgID int64, lang locales.Lang) {
+	s := db.GetUserSettings(tgID)
+	msg := render.Message(chatID, settingsText(s, lang))
+	msg.ReplyMarkup = settingsMarkup(s, lang)
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("showSettings error:", err)
+	}
+}
+
+func handleSettingsCallback(bot *tgbotapi.BotAPI, chatID int64, tgID int64, mesgID int, arg string, lang locales.Lang) {
+	s := db.GetUserSettings(tgID)
+	switch arg {
+	case "r24":
+		s.Reminder24 = !s.Reminder24
+	case "r3":
+		s.Reminder3 = !s.Reminder3
+	case "r1":
+		s.Reminder1 = !s.Reminder1
+	case "none":
+		s.Reminder24, s.Reminder3, s.Reminder1 = false, false, false
+	case "week":
+		s.NewWeekDM = !s.NewWeekDM
+	case "quiet":
+		setUserState(chatID, StateQuietHours)
+		sendText(bot, chatID, render.T(lang, "settings.ask_quiet"))
+		return
+	default:
+		return
+	}
+
+	if err := db.SaveUserSettings(tgID, s); err != nil {
+		log.Println(err)
+		sendText(bot, chatID, render.T(lang, "settings.save_failed"))
+		return
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, mesgID, settingsText(s, lang), settingsMarkup(s, lang))
+	edit.ParseMode = render.ParseMode
+	if _, err := bot.Send(edit); err != nil {
+		log.Println("handleSettingsCallback error:", err)
+	}
+}
+
+// Ввод тихих часов. Возвращает true, если сообщение обработано
+func handleSettingsInput(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state State, lang locales.Lang) bool {
+	if state != StateQuietHours {
+		return false
+	}
+	chatID := msg.Chat.ID
+	tgID := msg.From.ID
+
+	s := db.GetUserSettings(tgID)
+	text := strings.ToLower(strings.TrimSpace(msg.Text))
+	if text == "off" || text == "выкл" || text == "no" {
+		s.QuietFrom, s.QuietTo = sql.NullInt16{}, sql.NullInt16{}
+	} else {
+		m := quietHoursRe.FindStringSubmatch(text)
+		if m == nil {
+			sendText(bot, chatID, render.T(lang, "settings.quiet_invalid"))
+			return true
+		}
+		from, _ := strconv.Atoi(m[1])
+		to, _ := strconv.Atoi(m[2])
+		if from > 23 || to > 23 || from == to {
+			sendText(bot, chatID, render.T(lang, "settings.quiet_range_invalid"))
+			return true
+		}
+		s.QuietFrom = sql.NullInt16{Int16: int16(from), Valid: true}
+		s.QuietTo = sql.NullInt16{Int16: int16(to), Valid: true}
+	}
+
+	if err := db.SaveUserSettings(tgID, s); err != nil {
+		log.Println(err)
+		sendText(bot, chatID, render.T(lang, "settings.save_failed"))
+		return true
+	}
+	setUserState(chatID, StateNone)
+	showSettings(bot, chatID, tgID, lang)
+	return true
+}
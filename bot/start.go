@@ -0,0 +1,204 @@
+package bot
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Ссылки t.me/<бот>?start=<параметр>:
+//   ev_<id>  — открыть карточку события,
+//   ref_<telegram_id> — игрока пригласил другой игрок (/invite),
+//   inv_<код> — код приглашения клуба (/invite_code), может вести на событие.
+// Новый пользователь сначала проходит регистрацию в боте, и только потом видит событие
+
+const (
+	startEvent    = "ev_"
+	startReferral = "ref_"
+	startInvite   = "inv_"
+)
+
+// События, которые нужно показать пользователю после регистрации в боте
+var (
+	pendingEvents   = map[int64]int{}
+	pendingEventsMu sync.Mutex
+)
+
+func setPendingEvent(chatID int64, eventID int) {
+	pendingEventsMu.Lock()
+	defer pendingEventsMu.Unlock()
+	if eventID == 0 {
+		delete(pendingEvents, chatID)
+		return
+	}
+	pendingEvents[chatID] = eventID
+}
+
+func takePendingEvent(chatID int64) int {
+	pendingEventsMu.Lock()
+	defer pendingEventsMu.Unlock()
+	eventID := pendingEvents[chatID]
+	delete(pendingEvents, chatID)
+	return eventID
+}
+
+func handleStart(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state State, lang locales.Lang) {
+	chatID := msg.Chat.ID
+	tgID := msg.From.ID
+
+	// Если пользователь новый — добавляем и спрашиваем имя
+	user, err := db.GetOrCreateUser(tgID, chatID, msg.From.UserName, lang)
+	if err != nil {
+		// Не выходим: приветствие и ссылка на событие важнее, чем данные профиля
+		log.Println("db.GetOrCreateUser error:", err)
+		user = &db.User{TelegramID: tgID, ChatID: chatID}
+	}
+
+	eventID := 0
+	payload := msg.CommandArguments()
+	switch {
+	case strings.HasPrefix(payload, startEvent):
+		eventID, _ = strconv.Atoi(strings.TrimPrefix(payload, startEvent))
+	case strings.HasPrefix(payload, startReferral):
+		if referrer, err := strconv.ParseInt(strings.TrimPrefix(payload, startReferral), 10, 64); err == nil {
+			if err := db.SetUserReferrer(tgID, referrer); err != nil {
+				log.Println(err)
+			}
+		}
+	case strings.HasPrefix(payload, startInvite):
+		eventID, err = db.RedeemInviteCode(strings.TrimPrefix(payload, startInvite), tgID)
+		if err != nil {
+			log.Printf("handleStart: invite %q from %d: %v\n", payload, tgID, err)
+		}
+	case payload != "":
+		log.Printf("handleStart: unknown payload %q from %d\n", payload, tgID)
+	}
+
+	if user.Name == "" || user.Nickname == "" {
+		// Простой /start не отменяет ссылку, открытую раньше
+		if eventID != 0 {
+			setPendingEvent(chatID, eventID)
+		}
+		switch state {
+		case StateEnterName:
+			sendText(bot, chatID, render.T(lang, "start.ask_name"))
+		case StateEnterNickname:
+			sendText(bot, chatID, render.T(lang, "reg.ask_nickname"))
+		default:
+			sendText(bot, chatID, render.T(lang, "start.welcome"))
+			setUserState(chatID, StateEnterName)
+			sendText(bot, chatID, render.T(lang, "start.ask_name"))
+		}
+		return
+	}
+
+	if eventID == 0 {
+		sendText(bot, chatID, render.T(lang, "start.welcome"))
+		return
+	}
+	showEvent(bot, chatID, tgID, eventID, lang)
+}
+
+// Показать событие, ради которого пользователь открыл бота по ссылке
+func resumeStart(bot *tgbotapi.BotAPI, chatID int64, tgID int64, lang locales.Lang) {
+	if eventID := takePendingEvent(chatID); eventID != 0 {
+		showEvent(bot, chatID, tgID, eventID, lang)
+	}
+}
+
+func showEvent(bot *tgbotapi.BotAPI, chatID int64, tgID int64, eventID int, lang locales.Lang) {
+	ev, err := db.FetchEvent(int64(eventID))
+	if err != nil || !ev.StartsAt.After(time.Now()) {
+		sendText(bot, chatID, render.T(lang, "start.event_unavailable"))
+		return
+	}
+
+	text, markup := eventCard(ev, tgID, lang)
+	msg := render.Message(chatID, text)
+	if markup != nil {
+		msg.ReplyMarkup = *markup
+	}
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("showEvent error:", err)
+	}
+}
+
+func startLink(bot *tgbotapi.BotAPI, payload string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", bot.Self.UserName, payload)
+}
+
+func eventLink(bot *tgbotapi.BotAPI, eventID int) string {
+	return startLink(bot, fmt.Sprintf("%s%d", startEvent, eventID))
+}
+
+// /invite — ссылка игрока для приглашения друзей
+func handleInvite(bot *tgbotapi.BotAPI, chatID int64, tgID int64, lang locales.Lang) {
+	link := startLink(bot, fmt.Sprintf("%s%d", startReferral, tgID))
+	sendText(bot, chatID, render.T(lang, "invite.text", link, db.GetReferralCount(tgID)))
+}
+
+// /invite_code [id события] — новый код приглашения, без аргумента — список кодов
+func handleInviteCode(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	arg := strings.TrimSpace(msg.CommandArguments())
+	if arg == "list" {
+		codes := db.GetInviteCodes()
+		if len(codes) == 0 {
+			bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Кодов приглашения пока нет."))
+			return
+		}
+		text := "Коды приглашения:\n"
+		for _, c := range codes {
+			text += fmt.Sprintf("\n<code>%s</code> — новых игроков: %d", c.Code, c.Uses)
+			if c.EventID != 0 {
+				text += fmt.Sprintf(", событие #%d %s", c.EventID, render.Escape(c.EventTitle))
+			}
+		}
+		sendText(bot, msg.Chat.ID, text)
+		return
+	}
+
+	eventID := 0
+	if arg != "" {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			sendText(bot, msg.Chat.ID, "Формат: <code>/invite_code [id события]</code> или <code>/invite_code list</code>")
+			return
+		}
+		if _, err := db.FetchEvent(int64(id)); err != nil {
+			sendText(bot, msg.Chat.ID, "Событие не найдено.")
+			return
+		}
+		eventID = id
+	}
+
+	code, err := newInviteCode()
+	if err == nil {
+		err = db.CreateInviteCode(code, eventID, msg.From.ID)
+	}
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	audit(msg.From.ID, "invite_code", code, nil, eventID)
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "✅ Ссылка-приглашение:\n"+startLink(bot, startInvite+code)))
+}
+
+// Случайный код из 8 символов: только буквы и цифры, которые Telegram разрешает в параметре start
+func newInviteCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToLower(base32.StdEncoding.EncodeToString(b)), nil
+}
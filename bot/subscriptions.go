@@ -0,0 +1,105 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+	"laverdad-bot/services"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Постоянная запись на регулярные игры. Подписчиков серии записывают на каждую новую игру недели
+// (services.registerSubscribers), а пропустить неделю можно кнопкой под сообщением о записи
+
+func init() {
+	services.SubscriptionMarkup = subscriptionMarkup
+}
+
+func subscriptionMarkup(eventID int, series string, lang locales.Lang) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(callbackButton(locales.T(lang, "subscription.skip_week"), ActionCancel, eventID)),
+		tgbotapi.NewInlineKeyboardRow(callbackButton(locales.T(lang, "subscription.pause"), ActionSubscription, "hold-"+series)),
+	)
+}
+
+func subscriptionsMenu(tgID int64, lang locales.Lang) tgbotapi.InlineKeyboardMarkup {
+	subs := db.GetUserSubscriptions(tgID)
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, s := range services.WeeklySeries {
+		title := seriesTitle(s, lang)
+		sub, ok := subs[s.Key]
+		if !ok {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				callbackButton("➕ "+title, ActionSubscription, "on-"+s.Key),
+			))
+			continue
+		}
+
+		state, toggle, toggleArg := "✅ ", locales.T(lang, "subscription.pause"), "pause-"+s.Key
+		if sub.Paused {
+			state, toggle, toggleArg = "⏸ ", locales.T(lang, "subscription.resume"), "resume-"+s.Key
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			callbackButton(state+title, ActionSubscription, "off-"+s.Key),
+			callbackButton(toggle, ActionSubscription, toggleArg),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// «Пятница, 18:30» на языке игрока
+func seriesTitle(s services.Series, lang locales.Lang) string {
+	return fmt.Sprintf("%s, %02d:%02d", locales.T(lang, "series."+s.Key), s.Hour, s.Minute)
+}
+
+func showSubscriptions(bot *tgbotapi.BotAPI, chatID int64, tgID int64, lang locales.Lang) {
+	msg := render.Message(chatID, render.T(lang, "subscription.text"))
+	msg.ReplyMarkup = subscriptionsMenu(tgID, lang)
+	if _, err := bot.Send(msg); err != nil {
+		log.Println("showSubscriptions error:", err)
+	}
+}
+
+// Кнопки подписок: on-, off-, pause-, resume-<серия> в меню /subscriptions
+// и hold-<серия> под сообщением о записи — пауза, после которой это сообщение остаётся как есть
+func handleSubscriptionCallback(bot *tgbotapi.BotAPI, chatID int64, tgID int64, mesgID int, arg string, lang locales.Lang) {
+	action, key, _ := strings.Cut(arg, "-")
+	series, ok := services.LookupSeries(key)
+	if !ok {
+		return
+	}
+
+	var err error
+	switch action {
+	case "on":
+		err = db.Subscribe(tgID, series.Key)
+	case "off":
+		err = db.Unsubscribe(tgID, series.Key)
+	case "pause", "hold":
+		err = db.SetSubscriptionPaused(tgID, series.Key, true)
+	case "resume":
+		err = db.SetSubscriptionPaused(tgID, series.Key, false)
+	default:
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		sendText(bot, chatID, render.T(lang, "subscription.save_failed"))
+		return
+	}
+
+	if action == "hold" {
+		sendText(bot, chatID, render.T(lang, "subscription.paused", seriesTitle(series, lang)))
+		return
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, mesgID, render.T(lang, "subscription.text"), subscriptionsMenu(tgID, lang))
+	edit.ParseMode = render.ParseMode
+	if _, err := bot.Send(edit); err != nil {
+		log.Println("handleSubscriptionCallback error:", err)
+	}
+}
@@ -0,0 +1,157 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Редактирование шаблонов сообщений из Telegram:
+// /templates — список, /template <имя> [язык] — текст и пример,
+// /template_preview и /template_set <имя> <язык>, а со следующей строки — новый текст,
+// /template_reset <имя> <язык> — вернуть текст по умолчанию
+
+// /templates
+func handleListTemplates(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	changed := map[string]db.MessageTemplate{}
+	for _, t := range db.GetMessageTemplates() {
+		changed[t.Name+"/"+t.Language] = t
+	}
+
+	text := "Шаблоны сообщений:\n"
+	for _, n := range render.Templates {
+		text += fmt.Sprintf("\n<code>%s</code> — %s", n.Name, n.Title)
+		for _, lang := range n.Langs {
+			if t, ok := changed[n.Name+"/"+string(lang)]; ok {
+				text += fmt.Sprintf("\n  %s: изменён %s", lang, locales.ClubTime(t.UpdatedAt).Format("02.01.2006 15:04"))
+			}
+		}
+	}
+	text += "\n\nПосмотреть: <code>/template имя [ru|es|en]</code>"
+	sendText(bot, msg.Chat.ID, text)
+}
+
+// Разобрать «<имя> [язык]». Если язык не указан, берётся язык клуба
+func parseTemplateArgs(header string, langRequired bool) (*render.Named, locales.Lang, error) {
+	args := strings.Fields(header)
+	if len(args) == 0 || len(args) > 2 || (langRequired && len(args) != 2) {
+		return nil, "", fmt.Errorf("неверный формат команды")
+	}
+	n, ok := render.Lookup(args[0])
+	if !ok {
+		return nil, "", fmt.Errorf("шаблона «%s» нет, список — /templates", args[0])
+	}
+	lang := locales.DefaultLang
+	if len(args) == 2 {
+		lang = locales.Lang(strings.ToLower(args[1]))
+	}
+	if !n.HasLang(lang) {
+		return nil, "", fmt.Errorf("у шаблона «%s» нет языка %s", n.Name, lang)
+	}
+	return n, lang, nil
+}
+
+// /template <имя> [язык]
+func handleShowTemplate(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	n, lang, err := parseTemplateArgs(msg.CommandArguments(), false)
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v\nФормат: /template имя [ru|es|en]", err)))
+		return
+	}
+
+	src, custom := n.Source(lang)
+	state := "текст по умолчанию"
+	if custom {
+		state = "изменён администратором"
+	}
+	text := fmt.Sprintf("<b>%s</b> (%s), %s:\n\n<pre>%s</pre>\n\nПлейсхолдеры: %s\n\nИзменить: <code>/template_set %s %s</code>, а со следующей строки — новый текст.",
+		n.Name, lang, state, render.Escape(src), render.Escape(n.Placeholders), n.Name, lang)
+	sendText(bot, msg.Chat.ID, text)
+
+	previewTemplate(bot, msg.Chat.ID, n, src)
+}
+
+// /template_preview <имя> <язык>\n<текст>
+func handlePreviewTemplate(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	n, _, src, ok := parseTemplateInput(bot, msg, "template_preview")
+	if !ok {
+		return
+	}
+	previewTemplate(bot, msg.Chat.ID, n, src)
+}
+
+// /template_set <имя> <язык>\n<текст>
+func handleSetTemplate(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	n, lang, src, ok := parseTemplateInput(bot, msg, "template_set")
+	if !ok {
+		return
+	}
+	// Telegram должен принять пример, иначе шаблон с битой разметкой сломает рассылку
+	if !previewTemplate(bot, msg.Chat.ID, n, src) {
+		return
+	}
+
+	before, _ := n.Source(lang)
+	if err := n.Save(lang, src, msg.From.ID); err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	audit(msg.From.ID, "template_set", n.Name+"/"+string(lang), before, src)
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("✅ Шаблон %s (%s) сохранён. ☝️ Так он выглядит.", n.Name, lang)))
+}
+
+// /template_reset <имя> <язык>
+func handleResetTemplate(bot *tgbotapi.BotAPI, msg *tgbotapi.Message) {
+	n, lang, err := parseTemplateArgs(msg.CommandArguments(), true)
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v\nФормат: /template_reset имя ru|es|en", err)))
+		return
+	}
+
+	before, custom := n.Source(lang)
+	if !custom {
+		bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Шаблон не менялся, используется текст по умолчанию."))
+		return
+	}
+	if err := n.Reset(lang); err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return
+	}
+	audit(msg.From.ID, "template_reset", n.Name+"/"+string(lang), before, nil)
+	bot.Send(tgbotapi.NewMessage(msg.Chat.ID, fmt.Sprintf("✅ Для шаблона %s (%s) восстановлен текст по умолчанию.", n.Name, lang)))
+}
+
+func parseTemplateInput(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, command string) (*render.Named, locales.Lang, string, bool) {
+	header, src, _ := strings.Cut(msg.CommandArguments(), "\n")
+	n, lang, err := parseTemplateArgs(header, true)
+	if err == nil && strings.TrimSpace(src) == "" {
+		err = fmt.Errorf("текст шаблона пустой")
+	}
+	if err != nil {
+		sendText(bot, msg.Chat.ID, render.Escape(fmt.Sprintf("Ошибка: %v\nФормат: /%s имя ru|es|en, а со следующей строки — текст шаблона", err, command)))
+		return nil, "", "", false
+	}
+	return n, lang, src, true
+}
+
+// Отправить шаблон, заполненный примером данных. Возвращает false, если шаблон с ошибкой
+func previewTemplate(bot *tgbotapi.BotAPI, chatID int64, n *render.Named, src string) bool {
+	text, err := n.Preview(src)
+	if err == nil && strings.TrimSpace(text) == "" {
+		err = fmt.Errorf("шаблон дал пустое сообщение")
+	}
+	if err != nil {
+		sendText(bot, chatID, render.Escape(fmt.Sprintf("Ошибка: %v", err)))
+		return false
+	}
+	if _, err := bot.Send(render.Message(chatID, text)); err != nil {
+		sendText(bot, chatID, render.Escape(fmt.Sprintf("Telegram не принял сообщение (%v). Проверьте разметку.", err)))
+		return false
+	}
+	return true
+}
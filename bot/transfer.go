@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"laverdad-bot/db"
+	googleapi "laverdad-bot/google-api"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Событие, место на которое игрок передаёт, пока вводит ник получателя
+var (
+	pendingTransfers   = map[int64]int{}
+	pendingTransfersMu sync.Mutex
+)
+
+func setPendingTransfer(chatID int64, eventID int) {
+	pendingTransfersMu.Lock()
+	defer pendingTransfersMu.Unlock()
+	pendingTransfers[chatID] = eventID
+}
+
+func takePendingTransfer(chatID int64) int {
+	pendingTransfersMu.Lock()
+	defer pendingTransfersMu.Unlock()
+	eventID := pendingTransfers[chatID]
+	delete(pendingTransfers, chatID)
+	return eventID
+}
+
+// Кнопки передачи места: ask-<id события> у владельца, yes-<id передачи> и no-<id передачи> у получателя
+func handleTransferCallback(bot *tgbotapi.BotAPI, chatID int64, tgID int64, mesgID int, arg string, lang locales.Lang) {
+	action, idStr, _ := strings.Cut(arg, "-")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return
+	}
+
+	switch action {
+	case "ask":
+		if len(db.GetGuests(tgID, int(id))) > 0 {
+			sendText(bot, chatID, render.T(lang, "transfer.has_guests"))
+			return
+		}
+		setPendingTransfer(chatID, int(id))
+		setUserState(chatID, StateTransferTarget)
+		sendText(bot, chatID, render.T(lang, "transfer.ask_target"))
+
+	case "yes":
+		t, err := db.AcceptTransfer(id, tgID)
+		if err != nil {
+			log.Println("AcceptTransfer error:", err)
+			sendText(bot, chatID, transferErrorText(err, lang))
+			return
+		}
+		event, _ := db.FetchEvent(int64(t.EventID))
+		line, _ := db.GetRegistrationLineByID(t.RegistrationID)
+		sheetName := googleapi.SheetName(event.Title, event.StartsAt)
+		googleapi.Async(func() { googleapi.TransferRegistrationInSheet(sheetName, line) })
+
+		editTransferOffer(bot, chatID, mesgID, render.T(lang, "transfer.accepted", event.Title, event.StartsAt.Format("02.01.2006 15:04")))
+		from := db.GetUser(t.FromTelegramID)
+		to := db.GetUser(tgID)
+		sendText(bot, from.ChatID, render.T(locales.ParseLang(from.Language), "transfer.accepted_owner", to.Nickname, event.Title))
+
+	case "no":
+		t, err := db.DeclineTransfer(id, tgID)
+		if err != nil {
+			log.Println("DeclineTransfer error:", err)
+			sendText(bot, chatID, transferErrorText(err, lang))
+			return
+		}
+		event, _ := db.FetchEvent(int64(t.EventID))
+		editTransferOffer(bot, chatID, mesgID, render.T(lang, "transfer.declined"))
+		from := db.GetUser(t.FromTelegramID)
+		to := db.GetUser(tgID)
+		sendText(bot, from.ChatID, render.T(locales.ParseLang(from.Language), "transfer.declined_owner", to.Nickname, event.Title))
+	}
+}
+
+// Ввод ника получателя. Возвращает true, если сообщение обработано
+func handleTransferInput(bot *tgbotapi.BotAPI, msg *tgbotapi.Message, state State, lang locales.Lang) bool {
+	if state != StateTransferTarget {
+		return false
+	}
+	chatID := msg.Chat.ID
+	tgID := msg.From.ID
+
+	if msg.IsCommand() {
+		takePendingTransfer(chatID)
+		return leaveInput(bot, msg, lang)
+	}
+
+	target, err := db.FindUser(msg.Text)
+	if err != nil || target.Nickname == "" {
+		sendText(bot, chatID, render.T(lang, "transfer.target_not_found", strings.TrimSpace(msg.Text)))
+		return true
+	}
+	if target.TelegramID == tgID {
+		sendText(bot, chatID, render.T(lang, "transfer.self"))
+		return true
+	}
+	setUserState(chatID, StateNone)
+	eventID := takePendingTransfer(chatID)
+	if eventID == 0 {
+		return true
+	}
+
+	t, err := db.CreateTransfer(tgID, eventID, target.TelegramID)
+	if err != nil {
+		log.Println("CreateTransfer error:", err)
+		sendText(bot, chatID, transferErrorText(err, lang))
+		return true
+	}
+
+	event, _ := db.FetchEvent(int64(eventID))
+	owner := db.GetUser(tgID)
+	targetLang := locales.ParseLang(target.Language)
+	offer := render.Message(target.ChatID, render.T(targetLang, "transfer.offer", owner.Nickname, event.Title, event.StartsAt.Format("02.01.2006 15:04")))
+	offer.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		callbackButton(locales.T(targetLang, "transfer.accept"), ActionTransfer, fmt.Sprintf("yes-%d", t.ID)),
+		callbackButton(locales.T(targetLang, "transfer.decline"), ActionTransfer, fmt.Sprintf("no-%d", t.ID)),
+	))
+	if _, err := bot.Send(offer); err != nil {
+		log.Println("transfer offer error:", err)
+		sendText(bot, chatID, render.T(lang, "transfer.offer_failed", target.Nickname))
+		return true
+	}
+	sendText(bot, chatID, render.T(lang, "transfer.sent", target.Nickname))
+	return true
+}
+
+func transferErrorText(err error, lang locales.Lang) string {
+	switch {
+	case errors.Is(err, db.ErrTransferHasGuests):
+		return render.T(lang, "transfer.has_guests")
+	case errors.Is(err, db.ErrAlreadyRegistered):
+		return render.T(lang, "transfer.already_registered")
+	case errors.Is(err, db.ErrTransferUnavailable):
+		return render.T(lang, "transfer.unavailable")
+	}
+	return render.T(lang, "transfer.failed")
+}
+
+// Убрать кнопки из предложения, заменив его текст итогом
+func editTransferOffer(bot *tgbotapi.BotAPI, chatID int64, mesgID int, text string) {
+	if _, err := bot.Send(render.Edit(chatID, mesgID, text)); err != nil {
+		log.Println("editTransferOffer error:", err)
+	}
+}
@@ -0,0 +1,89 @@
+package bot
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+)
+
+const (
+	nameMinLen     = 2
+	nameMaxLen     = 32
+	nicknameMinLen = 2
+	nicknameMaxLen = 20
+)
+
+// Слова, которые нельзя использовать в качестве имени или ника
+var reservedWords = map[string]bool{
+	"admin":   true,
+	"админ":   true,
+	"bot":     true,
+	"бот":     true,
+	"host":    true,
+	"ведущий": true,
+	"судья":   true,
+	"guest":   true,
+	"гость":   true,
+	"null":    true,
+}
+
+// Допустимые символы кроме букв и цифр. Разметку ломать нечем — все сообщения экранируют HTML,
+// а остальная пунктуация и эмодзи мешают ведущему найти игрока по нику.
+// Подчёркивание разрешено: оно встречается в никах Telegram
+const allowedPunctuation = " -.'_"
+
+func validateName(text string, lang locales.Lang) (string, error) {
+	return validateProfileText(text, "validate.field_name", lang, nameMinLen, nameMaxLen)
+}
+
+func validateNickname(text string, telegramID int64, lang locales.Lang) (string, error) {
+	nickname, err := validateProfileText(text, "validate.field_nickname", lang, nicknameMinLen, nicknameMaxLen)
+	if err != nil {
+		return "", err
+	}
+	if db.NicknameTaken(nickname, telegramID) {
+		return "", errors.New(locales.T(lang, "validate.nickname_taken", nickname))
+	}
+	return nickname, nil
+}
+
+// fieldKey — ключ названия поля (в родительном падеже) для сообщений об ошибке
+func validateProfileText(text, fieldKey string, lang locales.Lang, minLen, maxLen int) (string, error) {
+	text = strings.Join(strings.Fields(text), " ")
+
+	if text == "" {
+		return "", errors.New(locales.T(lang, "validate.empty"))
+	}
+	if strings.HasPrefix(text, "/") {
+		return "", errors.New(locales.T(lang, "validate.command"))
+	}
+
+	length := utf8.RuneCountInString(text)
+	if length < minLen || length > maxLen {
+		return "", errors.New(locales.T(lang, "validate.length", locales.T(lang, fieldKey), minLen, maxLen))
+	}
+
+	hasLetter := false
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r), strings.ContainsRune(allowedPunctuation, r):
+		default:
+			return "", errors.New(locales.T(lang, "validate.chars"))
+		}
+	}
+	if !hasLetter {
+		return "", errors.New(locales.T(lang, "validate.letter"))
+	}
+
+	if reservedWords[strings.ToLower(text)] {
+		return "", errors.New(locales.T(lang, "validate.reserved", text))
+	}
+
+	return text, nil
+}
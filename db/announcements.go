@@ -0,0 +1,50 @@
+package db
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// Анонс недели в группе клуба
+type GroupAnnouncement struct {
+	ID        int64
+	ChatID    int64
+	MessageID int
+	EventIDs  []int64
+}
+
+func SaveGroupAnnouncement(chatID int64, messageID int, eventIDs []int64) error {
+	_, err := DB.Exec(`INSERT INTO group_announcements (chat_id, message_id, event_ids) VALUES ($1, $2, $3)`,
+		chatID, messageID, pq.Array(eventIDs))
+	if err != nil {
+		return fmt.Errorf("SaveGroupAnnouncement error: %v", err)
+	}
+	return nil
+}
+
+// Анонсы последних двух недель, в которых есть событие. Telegram разрешает боту
+// редактировать свои сообщения в группе и позже, но старые анонсы уже никому не нужны
+func GetGroupAnnouncementsForEvent(eventID int) []GroupAnnouncement {
+	rows, err := DB.Query(`
+	SELECT id, chat_id, message_id, event_ids
+	FROM group_announcements
+	WHERE event_ids @> ARRAY[$1]::bigint[] AND created_at > now() - interval '14 days'`, eventID)
+	if err != nil {
+		log.Println("GetGroupAnnouncementsForEvent error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var announcements []GroupAnnouncement
+	for rows.Next() {
+		var a GroupAnnouncement
+		if err := rows.Scan(&a.ID, &a.ChatID, &a.MessageID, pq.Array(&a.EventIDs)); err != nil {
+			log.Println("GetGroupAnnouncementsForEvent scan error:", err)
+			continue
+		}
+		announcements = append(announcements, a)
+	}
+	return announcements
+}
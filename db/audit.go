@@ -0,0 +1,107 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+type AuditRecord struct {
+	ID        int
+	ActorID   int64
+	ActorName string
+	Action    string
+	Target    string
+	Before    string
+	After     string
+	CreatedAt time.Time
+}
+
+type AuditFilter struct {
+	ActorID int64
+	Action  string
+	Since   time.Time
+	Limit   int
+}
+
+// Записать действие администратора. before/after сериализуются в JSON, nil — пустое значение
+func AddAuditRecord(actorID int64, action, target string, before, after any) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return fmt.Errorf("AddAuditRecord marshal error: %v", err)
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return fmt.Errorf("AddAuditRecord marshal error: %v", err)
+	}
+
+	_, err = DB.Exec(`
+	INSERT INTO admin_audit (actor_telegram_id, action, target, before, after)
+	VALUES ($1, $2, $3, $4, $5)`, actorID, action, target, beforeJSON, afterJSON)
+	if err != nil {
+		return fmt.Errorf("AddAuditRecord error: %v", err)
+	}
+	return nil
+}
+
+func auditJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func GetAuditRecords(filter AuditFilter) []AuditRecord {
+	var where []string
+	var args []any
+	if filter.ActorID != 0 {
+		args = append(args, filter.ActorID)
+		where = append(where, fmt.Sprintf("a.actor_telegram_id = $%d", len(args)))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		where = append(where, fmt.Sprintf("a.action = $%d", len(args)))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		where = append(where, fmt.Sprintf("a.created_at >= $%d", len(args)))
+	}
+
+	q := `
+	SELECT a.id, a.actor_telegram_id, coalesce(u.name, ''), a.action, a.target,
+	       coalesce(a.before::text, ''), coalesce(a.after::text, ''), a.created_at
+	FROM admin_audit a
+	LEFT JOIN users u ON u.telegram_id = a.actor_telegram_id`
+	if len(where) > 0 {
+		q += "\n\tWHERE " + strings.Join(where, " AND ")
+	}
+	q += "\n\tORDER BY a.created_at DESC"
+	if filter.Limit > 0 {
+		q += fmt.Sprintf("\n\tLIMIT %d", filter.Limit)
+	}
+
+	rows, err := DB.Query(q, args...)
+	if err != nil {
+		log.Println("GetAuditRecords error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var records []AuditRecord
+	for rows.Next() {
+		var r AuditRecord
+		err := rows.Scan(&r.ID, &r.ActorID, &r.ActorName, &r.Action, &r.Target, &r.Before, &r.After, &r.CreatedAt)
+		if err != nil {
+			log.Println("GetAuditRecords scan error:", err)
+			continue
+		}
+		records = append(records, r)
+	}
+	return records
+}
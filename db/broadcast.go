@@ -0,0 +1,69 @@
+package db
+
+import (
+	"fmt"
+	"log"
+)
+
+type AudienceKind string
+
+const (
+	AudienceAll     AudienceKind = "all"
+	AudienceEvent   AudienceKind = "event"
+	AudienceActive  AudienceKind = "active"
+	AudienceMembers AudienceKind = "members"
+)
+
+type Audience struct {
+	Kind    AudienceKind
+	EventID int
+	Weeks   int
+}
+
+// Получатели рассылки: chat_id доступных пользователей и количество тех, кто заблокировал бота
+func GetBroadcastAudience(a Audience) ([]int64, int, error) {
+	var filter string
+	var args []any
+
+	switch a.Kind {
+	case AudienceAll:
+		filter = "TRUE"
+	case AudienceEvent:
+		filter = "EXISTS (SELECT 1 FROM registrations r WHERE r.user_id = u.id AND r.event_id = $1)"
+		args = append(args, a.EventID)
+	case AudienceActive:
+		filter = `EXISTS (
+			SELECT 1 FROM registrations r JOIN events e ON e.id = r.event_id
+			WHERE r.user_id = u.id AND e.starts_at >= now() - make_interval(weeks => $1)
+		)`
+		args = append(args, a.Weeks)
+	case AudienceMembers:
+		filter = "EXISTS (SELECT 1 FROM memberships m WHERE m.user_id = u.id AND m.ends_at >= now())"
+	default:
+		return nil, 0, fmt.Errorf("неизвестная аудитория: %s", a.Kind)
+	}
+
+	q := fmt.Sprintf(`SELECT u.chat_id, u.blocked_at IS NOT NULL FROM users u WHERE u.chat_id > 0 AND %s`, filter)
+	rows, err := DB.Query(q, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("GetBroadcastAudience error: %v", err)
+	}
+	defer rows.Close()
+
+	var chatIDs []int64
+	blocked := 0
+	for rows.Next() {
+		var chatID int64
+		var isBlocked bool
+		if err := rows.Scan(&chatID, &isBlocked); err != nil {
+			log.Println("GetBroadcastAudience scan error:", err)
+			continue
+		}
+		if isBlocked {
+			blocked++
+			continue
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+	return chatIDs, blocked, nil
+}
@@ -2,12 +2,15 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"time"
 
+	"laverdad-bot/locales"
+
 	_ "github.com/lib/pq"
 )
 
@@ -15,9 +18,11 @@ type User struct {
 	ID         int
 	TelegramID int64
 	ChatID     int64
+	UserName   string
 	Name       string
 	Nickname   string
 	Phone      string
+	Language   string
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
@@ -28,6 +33,17 @@ type Event struct {
 	Description string
 	Location    string
 	StartsAt    time.Time
+	Series      string // серия регулярных игр, для событий из недельного расписания
+	// Переводы описания по языкам. Заполняется только FetchEvent и при создании события
+	Descriptions map[locales.Lang]string
+}
+
+// Описание события на языке lang, а если перевода нет — основное
+func (e Event) DescriptionIn(lang locales.Lang) string {
+	if d := e.Descriptions[lang]; d != "" {
+		return d
+	}
+	return e.Description
 }
 
 type Registration struct {
@@ -44,6 +60,9 @@ type AdminRegistration struct {
 	Name       string
 	Nickname   string
 	TelegramID int64
+	Membership bool
+	Blocked    bool
+	GuestOf    string // ник игрока, который привёл гостя
 }
 
 type RegistrationLine struct {
@@ -52,13 +71,21 @@ type RegistrationLine struct {
 	UserName     sql.NullString
 	Name         string
 	NickName     string
+	Guest        bool   // регистрация без Telegram: гость игрока или записанный администратором
+	GuestOf      string // ник игрока, который привёл гостя
 	Status       string
+	MembershipID sql.NullInt64
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
 
 var DB *sql.DB
 
+const (
+	pingAttempts   = 10
+	pingRetryDelay = 3 * time.Second
+)
+
 func InitDB(connStr string) {
 	var err error
 	DB, err = sql.Open("postgres", connStr)
@@ -66,10 +93,19 @@ func InitDB(connStr string) {
 		log.Fatal(err)
 	}
 
-	err = DB.Ping()
-	if err != nil {
-		log.Fatal(err)
+	// При загрузке Raspberry Pi Postgres может подняться позже бота — ждём его
+	for attempt := 1; ; attempt++ {
+		err = DB.Ping()
+		if err == nil {
+			break
+		}
+		if attempt == pingAttempts {
+			log.Fatal(err)
+		}
+		log.Printf("Postgres is not ready (attempt %d/%d): %v\n", attempt, pingAttempts, err)
+		time.Sleep(pingRetryDelay)
 	}
+	log.Println("Postgres is ready")
 
 	// Таблицы
 	_, err = DB.Exec(`
@@ -85,7 +121,7 @@ func InitDB(connStr string) {
 		id SERIAL PRIMARY KEY,
 		title TEXT NOT NULL,
 		description TEXT NOT NULL,
-		starts_at TIMESTAMP NOT NULL
+		starts_at TIMESTAMPTZ NOT NULL
 	);
 
 	CREATE TABLE IF NOT EXISTS registrations (
@@ -100,6 +136,15 @@ func InitDB(connStr string) {
 	}
 }
 
+func CloseDB() {
+	if DB == nil {
+		return
+	}
+	if err := DB.Close(); err != nil {
+		log.Println("CloseDB error:", err)
+	}
+}
+
 // Проверка, есть ли пользователь
 func UserExists(telegramID int64) bool {
 	var exists bool
@@ -114,33 +159,52 @@ func UserExists(telegramID int64) bool {
 func GetUser(telegramID int64) User {
 	var user User
 	q := `
-        SELECT id, telegram_id, chat_id, name, nickname, phone, created_at, updated_at
+        SELECT id, telegram_id, chat_id, coalesce(username, ''), coalesce(name, ''), coalesce(nickname, ''), coalesce(phone, ''), coalesce(language, ''), created_at, updated_at
         FROM users WHERE telegram_id=$1
     `
-	DB.QueryRow(q, telegramID).Scan(&user.ID, &user.TelegramID, &user.ChatID, &user.Name, &user.Nickname, &user.Phone, &user.CreatedAt, &user.UpdatedAt)
+	DB.QueryRow(q, telegramID).Scan(&user.ID, &user.TelegramID, &user.ChatID, &user.UserName, &user.Name, &user.Nickname, &user.Phone, &user.Language, &user.CreatedAt, &user.UpdatedAt)
 
 	return user
 }
 
-func GetOrCreateUser(telegramID, chatID int64, userName string) (*User, error) {
+// Новому пользователю сразу сохраняется язык — по language_code из Telegram
+func GetOrCreateUser(telegramID, chatID int64, userName string, language locales.Lang) (*User, error) {
 	var user User
 	err := DB.QueryRow(`
-        SELECT id, telegram_id, chat_id, name, nickname, phone, created_at, updated_at
+        SELECT id, telegram_id, chat_id, coalesce(username, ''), coalesce(name, ''), coalesce(nickname, ''), coalesce(phone, ''), coalesce(language, ''), created_at, updated_at
         FROM users WHERE telegram_id=$1
-    `, telegramID).Scan(&user.ID, &user.TelegramID, &user.ChatID, &user.Name, &user.Nickname, &user.Phone, &user.CreatedAt, &user.UpdatedAt)
+    `, telegramID).Scan(&user.ID, &user.TelegramID, &user.ChatID, &user.UserName, &user.Name, &user.Nickname, &user.Phone, &user.Language, &user.CreatedAt, &user.UpdatedAt)
 
 	if err == sql.ErrNoRows {
 		_, err = DB.Exec(`
-            INSERT INTO users (telegram_id, chat_id, username) VALUES ($1, $2, $3)
-        `, telegramID, chatID, userName)
+            INSERT INTO users (telegram_id, chat_id, username, language) VALUES ($1, $2, $3, $4)
+        `, telegramID, chatID, userName, string(language))
 		if err != nil {
 			return nil, err
 		}
-		return &User{TelegramID: telegramID, ChatID: chatID}, nil
+		return &User{TelegramID: telegramID, ChatID: chatID, Language: string(language)}, nil
 	}
 	return &user, err
 }
 
+// Поиск пользователя по telegram_id, @username или игровому нику
+func FindUser(query string) (User, error) {
+	var user User
+	query = strings.TrimPrefix(strings.TrimSpace(query), "@")
+	q := `
+        SELECT id, telegram_id, chat_id, coalesce(username, ''), coalesce(name, ''), coalesce(nickname, ''), coalesce(language, '')
+        FROM users
+        WHERE telegram_id::text = $1 OR lower(username) = lower($1) OR lower(nickname) = lower($1)
+        ORDER BY id
+        LIMIT 1
+    `
+	err := DB.QueryRow(q, query).Scan(&user.ID, &user.TelegramID, &user.ChatID, &user.UserName, &user.Name, &user.Nickname, &user.Language)
+	if err != nil {
+		return user, fmt.Errorf("пользователь %s не найден", query)
+	}
+	return user, nil
+}
+
 // Создание пользователя
 func CreateUser(telegramID int64, chatID int64) error {
 	_, err := DB.Exec(`INSERT INTO users (telegram_id, chat_id) VALUES ($1, $2) ON CONFLICT (telegram_id) DO NOTHING`, telegramID, chatID)
@@ -149,19 +213,96 @@ func CreateUser(telegramID int64, chatID int64) error {
 
 // Обновление имени
 func UpdateUserName(telegramID int64, name string) error {
-	_, err := DB.Exec(`UPDATE users SET name=$1 WHERE telegram_id=$2`, name, telegramID)
+	_, err := DB.Exec(`UPDATE users SET name=$1, updated_at=now() WHERE telegram_id=$2`, name, telegramID)
 	return err
 }
 
 // Обновление ника
 func UpdateUserNickname(telegramID int64, nickname string) error {
-	_, err := DB.Exec(`UPDATE users SET nickname=$1 WHERE telegram_id=$2`, nickname, telegramID)
+	_, err := DB.Exec(`UPDATE users SET nickname=$1, updated_at=now() WHERE telegram_id=$2`, nickname, telegramID)
+	return err
+}
+
+// Занят ли ник другим пользователем (без учёта регистра)
+func NicknameTaken(nickname string, telegramID int64) bool {
+	var exists bool
+	err := DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE lower(nickname)=lower($1) AND telegram_id<>$2)`, nickname, telegramID).Scan(&exists)
+	if err != nil {
+		log.Println("NicknameTaken error:", err)
+		return false
+	}
+	return exists
+}
+
+// Пользователи с совпадающими (без учёта регистра) никами
+func GetNicknameDuplicates() [][]User {
+	rows, err := DB.Query(`
+	SELECT id, telegram_id, coalesce(username, ''), coalesce(name, ''), nickname
+	FROM users
+	WHERE lower(nickname) IN (
+		SELECT lower(nickname) FROM users
+		WHERE nickname IS NOT NULL AND nickname <> ''
+		GROUP BY lower(nickname) HAVING COUNT(*) > 1
+	)
+	ORDER BY lower(nickname), id`)
+	if err != nil {
+		log.Println("GetNicknameDuplicates error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var groups [][]User
+	for rows.Next() {
+		var u User
+		err := rows.Scan(&u.ID, &u.TelegramID, &u.UserName, &u.Name, &u.Nickname)
+		if err != nil {
+			log.Println("GetNicknameDuplicates scan error:", err)
+			continue
+		}
+		last := len(groups) - 1
+		if last >= 0 && strings.EqualFold(groups[last][0].Nickname, u.Nickname) {
+			groups[last] = append(groups[last], u)
+		} else {
+			groups = append(groups, []User{u})
+		}
+	}
+	return groups
+}
+
+// Пользователь заблокировал бота: больше не отправляем ему сообщения
+func MarkUserBlocked(chatID int64) error {
+	_, err := DB.Exec(`UPDATE users SET blocked_at=now() WHERE chat_id=$1 AND blocked_at IS NULL`, chatID)
+	if err != nil {
+		return fmt.Errorf("MarkUserBlocked error: %v", err)
+	}
+	return nil
+}
+
+// Пользователь снова доступен (разблокировал бота или написал ему)
+func MarkUserReachable(chatID int64) error {
+	_, err := DB.Exec(`UPDATE users SET blocked_at=NULL WHERE chat_id=$1 AND blocked_at IS NOT NULL`, chatID)
+	if err != nil {
+		return fmt.Errorf("MarkUserReachable error: %v", err)
+	}
+	return nil
+}
+
+// Язык интерфейса пользователя
+func UpdateUserLanguage(telegramID int64, language locales.Lang) error {
+	_, err := DB.Exec(`UPDATE users SET language=$1, updated_at=now() WHERE telegram_id=$2`, string(language), telegramID)
+	return err
+}
+
+// Обновление телефона
+func UpdateUserPhone(telegramID int64, phone string) error {
+	_, err := DB.Exec(`UPDATE users SET phone=$1, updated_at=now() WHERE telegram_id=$2`, phone, telegramID)
 	return err
 }
 
 // Получить список событий
 func GetEvents() []Event {
-	rows, err := DB.Query(`SELECT id, title, description, location, starts_at FROM events WHERE starts_at >= CURRENT_DATE ORDER BY starts_at`)
+	// CURRENT_DATE считается в поясе сервера БД, поэтому начало дня берём по часам клуба
+	rows, err := DB.Query(`SELECT id, title, description, location, starts_at FROM events WHERE starts_at >= $1 ORDER BY starts_at`, locales.Today())
 	if err != nil {
 		log.Println("GetEvents error:", err)
 		return nil
@@ -176,14 +317,52 @@ func GetEvents() []Event {
 			log.Println("GetEvents scan error:", err)
 			continue
 		}
+		e.StartsAt = locales.ClubTime(e.StartsAt)
 		events = append(events, e)
 	}
 	return events
 }
 
-func CreateEvent(event Event) error {
-	_, err := DB.Exec(`INSERT INTO events (title, description, location, starts_at) VALUES ($1, $2, $3, $4)`, event.Title, event.Description, event.Location, event.StartsAt)
-	return err
+var ErrEventExists = errors.New("event of this series already exists")
+
+// Создать событие. Возвращает его id или ErrEventExists, если игра той же серии
+// на это время уже есть — например, /generate запустили второй раз
+func CreateEvent(event Event) (int, error) {
+	translations, err := json.Marshal(event.Descriptions)
+	if err != nil {
+		return 0, fmt.Errorf("CreateEvent marshal error: %v", err)
+	}
+	if event.Descriptions == nil {
+		translations = []byte("{}")
+	}
+	var id int
+	err = DB.QueryRow(`INSERT INTO events (title, description, location, starts_at, description_i18n, series) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+	ON CONFLICT (series, starts_at) WHERE series IS NOT NULL DO NOTHING
+	RETURNING id`, event.Title, event.Description, event.Location, event.StartsAt, translations, event.Series).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrEventExists
+	}
+	return id, err
+}
+
+// Сохранить перевод описания события на язык lang
+func SetEventDescription(eventID int, lang locales.Lang, description string) error {
+	_, err := DB.Exec(`UPDATE events SET description_i18n = description_i18n || jsonb_build_object($2::text, $3::text) WHERE id = $1`,
+		eventID, string(lang), description)
+	if err != nil {
+		return fmt.Errorf("SetEventDescription error: %v", err)
+	}
+	return nil
+}
+
+// Отметить, что сообщение о кворуме отправлено. false — если его уже отправляли
+func ClaimQuorum(eventID int) (bool, error) {
+	res, err := DB.Exec(`UPDATE events SET quorum_sent_at = now() WHERE id = $1 AND quorum_sent_at IS NULL`, eventID)
+	if err != nil {
+		return false, fmt.Errorf("ClaimQuorum error: %v", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
 }
 
 func RegistrationExists(eventID int64, userID int64) bool {
@@ -199,10 +378,13 @@ func RegistrationExists(eventID int64, userID int64) bool {
 func GetRegistrationsByEvent(eventID int) []AdminRegistration {
 	var regs []AdminRegistration
 	q := `
-	SELECT r.id, e.title, u.name, u.nickname, u.telegram_id
+	SELECT r.id, e.title, coalesce(u.name, r.guest_name, ''), coalesce(u.nickname, ''), coalesce(u.telegram_id, 0),
+		r.membership_id IS NOT NULL, u.blocked_at IS NOT NULL, coalesce(hu.nickname, '')
 	FROM registrations r
 	JOIN events e ON r.event_id = e.id
-	JOIN users u ON r.user_id = u.id
+	LEFT JOIN users u ON r.user_id = u.id
+	LEFT JOIN registrations hr ON hr.id = r.host_registration_id
+	LEFT JOIN users hu ON hu.id = hr.user_id
 	WHERE e.id = $1
 	ORDER BY r.created_at
 	`
@@ -216,7 +398,7 @@ func GetRegistrationsByEvent(eventID int) []AdminRegistration {
 	for rows.Next() {
 		var r AdminRegistration
 
-		err := rows.Scan(&r.ID, &r.Title, &r.Name, &r.Nickname, &r.TelegramID)
+		err := rows.Scan(&r.ID, &r.Title, &r.Name, &r.Nickname, &r.TelegramID, &r.Membership, &r.Blocked, &r.GuestOf)
 		if err != nil {
 			log.Printf("GetRegistrations scan error: %v\n", err)
 			continue
@@ -240,39 +422,92 @@ func GetEventParticipantsCount(eventID int) (int, error) {
 
 // Зарегистрировать пользователя на событие
 func RegisterUserToEvent(telegramID int64, eventID int) error {
+	return registerUser(telegramID, eventID, 0)
+}
+
+// Зарегистрировать пользователя на событие от имени администратора adminID
+func RegisterUserByAdmin(telegramID int64, eventID int, adminID int64) error {
+	return registerUser(telegramID, eventID, adminID)
+}
+
+// Абонемент пользователя $1, действующий на дату события $2, с неизрасходованными играми
+const availableMembershipQuery = `(
+		SELECT m.id
+		FROM memberships m
+		JOIN events e ON e.id = $2
+		WHERE m.user_id = $1
+		  AND m.starts_at <= e.starts_at AND m.ends_at >= e.starts_at
+		  AND (m.games_included = 0 OR (SELECT COUNT(*) FROM registrations r WHERE r.membership_id = m.id) < m.games_included)
+		ORDER BY m.ends_at
+		LIMIT 1
+	)`
+
+func registerUser(telegramID int64, eventID int, registeredBy int64) error {
 	var userID int
 	err := DB.QueryRow(`SELECT id FROM users WHERE telegram_id=$1`, telegramID).Scan(&userID)
 	if err != nil {
 		return fmt.Errorf("пользователь не найден")
 	}
 
-	_, err = DB.Exec(`INSERT INTO registrations (user_id, event_id) VALUES ($1, $2)`, userID, eventID)
+	tx, err := DB.Begin()
 	if err != nil {
 		return fmt.Errorf("не удалось зарегистрироваться: %v", err)
 	}
-	return nil
+	defer tx.Rollback()
+
+	// Если у пользователя есть действующий на дату события абонемент с неизрасходованными играми — списываем игру с него
+	var regID int64
+	err = tx.QueryRow(`
+	INSERT INTO registrations (user_id, event_id, registered_by, membership_id)
+	VALUES ($1, $2, NULLIF($3, 0), `+availableMembershipQuery+`) RETURNING id`, userID, eventID, registeredBy).Scan(&regID)
+	if err != nil {
+		return fmt.Errorf("не удалось зарегистрироваться: %v", err)
+	}
+
+	// Запланированные напоминания удаляются вместе с регистрацией при её отмене (on delete cascade)
+	if err := scheduleReminders(tx, regID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
+const registrationLineQuery = `
+	SELECT r.id, coalesce(u.telegram_id, 0), u.username, coalesce(u.name, r.guest_name, ''), coalesce(u.nickname, ''),
+		r.user_id IS NULL, coalesce(hu.nickname, ''), r.status, r.membership_id, r.created_at, r.updated_at
+	FROM registrations r
+	LEFT JOIN users u ON u.id = r.user_id
+	LEFT JOIN registrations hr ON hr.id = r.host_registration_id
+	LEFT JOIN users hu ON hu.id = hr.user_id
+`
+
 func GetRegistrationLine(telegramID int, eventID int) (RegistrationLine, error) {
+	return scanRegistrationLine(DB.QueryRow(registrationLineQuery+`WHERE r.event_id = $1 AND u.telegram_id = $2 LIMIT 1`, eventID, telegramID))
+}
+
+func GetRegistrationLineByID(regID int) (RegistrationLine, error) {
+	return scanRegistrationLine(DB.QueryRow(registrationLineQuery+`WHERE r.id = $1`, regID))
+}
+
+func scanRegistrationLine(row *sql.Row) (RegistrationLine, error) {
 	var line RegistrationLine
-	q := `
-		SELECT r.id, u.username, u.name, u.nickname, r.status, r.created_at, r.updated_at
-		FROM registrations r
-		JOIN users u ON u.id = r.user_id
-		WHERE r.event_id = $1
-		  AND u.telegram_id = $2
-		LIMIT 1
-	`
-	err := DB.QueryRow(q, eventID, telegramID).Scan(&line.ID, &line.UserName, &line.Name, &line.NickName, &line.Status, &line.CreatedAt, &line.UpdatedAt)
+	var telegramID int64
+	err := row.Scan(&line.ID, &telegramID, &line.UserName, &line.Name, &line.NickName, &line.Guest, &line.GuestOf, &line.Status, &line.MembershipID, &line.CreatedAt, &line.UpdatedAt)
 	if err != nil {
 		log.Printf("GetRegistrationLine QueryRow ERROR! %v\n", err)
 		return line, fmt.Errorf("RegistrationLine query error: %v", err)
 	}
-	line.TelegramLink = fmt.Sprintf("tg://user?id=%s", strconv.Itoa(telegramID))
+	if telegramID != 0 {
+		line.TelegramLink = TelegramLink(telegramID)
+	}
 
 	return line, nil
 }
 
+// Ссылка на пользователя Telegram, которой он отмечен в таблице
+func TelegramLink(telegramID int64) string {
+	return fmt.Sprintf("tg://user?id=%d", telegramID)
+}
+
 // Получить регистрации пользователя
 func GetUserRegistrations(telegramID int64) []Registration {
 	var regs []Registration
@@ -297,6 +532,53 @@ func GetUserRegistrations(telegramID int64) []Registration {
 			log.Printf("GetUserRegistrations scan error: %v\n", err)
 			continue
 		}
+		r.StartsAt = locales.ClubTime(r.StartsAt)
+		regs = append(regs, r)
+	}
+
+	return regs
+}
+
+// Регистрации пользователя на предстоящие события (для синхронизации с таблицей)
+func GetUserUpcomingRegistrations(telegramID int64) []Registration {
+	return queryUserRegistrationRows(`
+	SELECT r.id, e.title, e.starts_at
+	FROM registrations r
+	JOIN users u ON r.user_id = u.id
+	JOIN events e ON r.event_id = e.id
+	WHERE u.telegram_id=$1 AND e.starts_at >= $2
+	ORDER BY e.starts_at`, telegramID, locales.Today())
+}
+
+// Все регистрации пользователя, включая прошедшие события
+func GetUserAllRegistrations(telegramID int64) []Registration {
+	return queryUserRegistrationRows(`
+	SELECT r.id, e.title, e.starts_at
+	FROM registrations r
+	JOIN users u ON r.user_id = u.id
+	JOIN events e ON r.event_id = e.id
+	WHERE u.telegram_id=$1
+	ORDER BY e.starts_at`, telegramID)
+}
+
+func queryUserRegistrationRows(q string, args ...any) []Registration {
+	var regs []Registration
+
+	rows, err := DB.Query(q, args...)
+	if err != nil {
+		log.Printf("queryUserRegistrationRows error: %v\n", err)
+		return regs
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Registration
+		err := rows.Scan(&r.ID, &r.Title, &r.StartsAt)
+		if err != nil {
+			log.Printf("queryUserRegistrationRows scan error: %v\n", err)
+			continue
+		}
+		r.StartsAt = locales.ClubTime(r.StartsAt)
 		regs = append(regs, r)
 	}
 
@@ -331,42 +613,38 @@ func CancelUserRegistration(telegramID int64, eventID int) error {
 		return fmt.Errorf("ERROR SELECT user with telegram_id: %d", int(telegramID))
 	}
 
-	_, err = DB.Exec(`DELETE FROM registrations WHERE user_id=$1 AND event_id=$2`, userID, eventID)
+	tx, err := DB.Begin()
 	if err != nil {
 		return fmt.Errorf("не удалось отменить регистрацию: %v", err)
 	}
-	return nil
-}
+	defer tx.Rollback()
 
-func UpdateRegistrationNotificationStatus(eventIDS []string, column string) error {
-	var regIDS []string
-	rows, err := DB.Query(`SELECT id FROM registrations WHERE event_id IN($1)`, strings.Join(eventIDS, ", "))
+	// Предложение передать место теряет смысл вместе с местом, а в истории передач остаётся
+	_, err = tx.Exec(`
+	UPDATE registration_transfers SET status = 'cancelled', resolved_at = now()
+	WHERE status = 'pending' AND registration_id IN (SELECT id FROM registrations WHERE user_id=$1 AND event_id=$2)`, userID, eventID)
 	if err != nil {
-		return fmt.Errorf("UpdateRegistrationNotificationStatus error: %v", err)
-	}
-	defer rows.Close()
-	for rows.Next() {
-		var regID int
-		err = rows.Scan(&regID)
-		if err != nil {
-			log.Printf("UpdateRegistrationNotificationStatus scan error: %v\n", err)
-			continue
-		}
-		regIDS = append(regIDS, strconv.Itoa(regID))
+		return fmt.Errorf("не удалось отменить передачу места: %v", err)
 	}
 
-	q := fmt.Sprintf("UPDATE registrations SET %s='t' WHERE id IN(%s)", column, strings.Join(regIDS, ", "))
-	_, err = DB.Exec(q)
+	_, err = tx.Exec(`DELETE FROM registrations WHERE user_id=$1 AND event_id=$2`, userID, eventID)
 	if err != nil {
-		return fmt.Errorf("ERROR UPDATE registrations: %v", err)
+		return fmt.Errorf("не удалось отменить регистрацию: %v", err)
 	}
-	return nil
+	return tx.Commit()
 }
 
 func FetchEvent(id int64) (Event, error) {
 	var out Event
-	q := `select id, title, coalesce(description,''), location, starts_at from events where id = $1 limit 1`
-	err := DB.QueryRow(q, id).Scan(&out.ID, &out.Title, &out.Description, &out.Location, &out.StartsAt)
+	var translations []byte
+	q := `select id, title, coalesce(description,''), location, starts_at, description_i18n from events where id = $1 limit 1`
+	err := DB.QueryRow(q, id).Scan(&out.ID, &out.Title, &out.Description, &out.Location, &out.StartsAt, &translations)
+	out.StartsAt = locales.ClubTime(out.StartsAt)
+	if err == nil {
+		if jsonErr := json.Unmarshal(translations, &out.Descriptions); jsonErr != nil {
+			log.Println("FetchEvent translations error:", jsonErr)
+		}
+	}
 
 	return out, err
 }
@@ -391,61 +669,42 @@ func GetUpcomingEvents(d time.Duration) []Event {
 			log.Println("ERROR Scan GetUpcomingEvents:", err)
 			continue
 		}
+		e.StartsAt = locales.ClubTime(e.StartsAt)
 		events = append(events, e)
 	}
 	return events
 }
 
-func GetEventParticipants(eventID int) ([]User, error) {
-	q := fmt.Sprintf(`
-	SELECT u.id, u.
-	FROM registrations r
-	JOIN users u ON r.user_id = u.id
-	WHERE r.event_id = %d
-	`, eventID)
-	rows, err := DB.Query(q)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var users []User
-	for rows.Next() {
-		var u User
-		err := rows.Scan(&u.ID, &u.ChatID, &u.TelegramID, &u.Name, &u.Nickname, &u.CreatedAt, &u.UpdatedAt)
-		if err != nil {
-			log.Println("GetEventParticipants scan error:", err)
-			continue
-		}
-		users = append(users, u)
-	}
-	return users, nil
+// Участник события: игрок или гость без Telegram
+type Participant struct {
+	TelegramID int64 // 0 у гостя
+	Name       string
+	Nickname   string
+	GuestOf    string // ник игрока, который привёл гостя
 }
 
-func GetEventParticipantsWithFlag(eventID int, column string) []User {
-	q := fmt.Sprintf(`
-	SELECT u.chat_id
+func GetEventParticipants(eventID int) ([]Participant, error) {
+	rows, err := DB.Query(`
+	SELECT coalesce(u.telegram_id, 0), coalesce(u.name, r.guest_name, ''), coalesce(u.nickname, ''), coalesce(hu.nickname, '')
 	FROM registrations r
-	JOIN users u ON r.user_id = u.id
-	WHERE r.event_id = %d
-	AND r.%s = 'f'
-	`, eventID, column)
-	rows, err := DB.Query(q)
+	LEFT JOIN users u ON r.user_id = u.id
+	LEFT JOIN registrations hr ON hr.id = r.host_registration_id
+	LEFT JOIN users hu ON hu.id = hr.user_id
+	WHERE r.event_id = $1
+	ORDER BY r.created_at`, eventID)
 	if err != nil {
-		log.Println("GetEventParticipants error:", err)
-		return nil
+		return nil, fmt.Errorf("GetEventParticipants error: %v", err)
 	}
 	defer rows.Close()
 
-	var users []User
+	var participants []Participant
 	for rows.Next() {
-		var u User
-		err := rows.Scan(&u.ChatID)
-		if err != nil {
+		var p Participant
+		if err := rows.Scan(&p.TelegramID, &p.Name, &p.Nickname, &p.GuestOf); err != nil {
 			log.Println("GetEventParticipants scan error:", err)
 			continue
 		}
-		users = append(users, u)
+		participants = append(participants, p)
 	}
-	return users
+	return participants, nil
 }
@@ -0,0 +1,12 @@
+package db
+
+import "fmt"
+
+// Сохранить информацию о сообщении, которое не удалось доставить
+func RecordDeliveryFailure(chatID int64, errorCode int, description string) error {
+	_, err := DB.Exec(`INSERT INTO delivery_failures (chat_id, error_code, description) VALUES ($1, $2, $3)`, chatID, errorCode, description)
+	if err != nil {
+		return fmt.Errorf("RecordDeliveryFailure error: %v", err)
+	}
+	return nil
+}
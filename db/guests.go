@@ -0,0 +1,140 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Сколько гостей игрок может привести на одну игру
+const MaxGuestsPerPlayer = 2
+
+var ErrTooManyGuests = errors.New("too many guests")
+
+type Guest struct {
+	RegistrationID int
+	Name           string
+}
+
+// Записать гостя игрока на событие. Игрок должен быть записан сам;
+// гость удаляется вместе с его регистрацией. Возвращает id регистрации гостя
+func AddGuest(hostTelegramID int64, eventID int, name string) (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("AddGuest begin error: %v", err)
+	}
+	defer tx.Rollback()
+
+	var hostRegID int
+	err = tx.QueryRow(`
+	SELECT r.id FROM registrations r
+	JOIN users u ON u.id = r.user_id
+	WHERE u.telegram_id = $1 AND r.event_id = $2
+	FOR UPDATE OF r`, hostTelegramID, eventID).Scan(&hostRegID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("игрок не записан на событие")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("AddGuest select error: %v", err)
+	}
+
+	var count int
+	if err := tx.QueryRow(`SELECT count(*) FROM registrations WHERE host_registration_id = $1`, hostRegID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("AddGuest count error: %v", err)
+	}
+	if count >= MaxGuestsPerPlayer {
+		return 0, ErrTooManyGuests
+	}
+
+	var regID int
+	err = tx.QueryRow(`
+	INSERT INTO registrations (event_id, guest_name, host_registration_id) VALUES ($1, $2, $3)
+	RETURNING id`, eventID, name, hostRegID).Scan(&regID)
+	if err != nil {
+		return 0, fmt.Errorf("AddGuest insert error: %v", err)
+	}
+	return regID, tx.Commit()
+}
+
+// Записать на событие человека без Telegram от имени администратора adminID
+func AddWalkIn(eventID int, name string, adminID int64) (int, error) {
+	var regID int
+	err := DB.QueryRow(`
+	INSERT INTO registrations (event_id, guest_name, registered_by) VALUES ($1, $2, $3)
+	RETURNING id`, eventID, name, adminID).Scan(&regID)
+	if err != nil {
+		return 0, fmt.Errorf("AddWalkIn error: %v", err)
+	}
+	return regID, nil
+}
+
+// id регистраций всех гостей, которых игрок когда-либо привёл
+func GetHostedGuestIDs(hostTelegramID int64) []int {
+	rows, err := DB.Query(`
+	SELECT g.id
+	FROM registrations g
+	JOIN registrations r ON r.id = g.host_registration_id
+	JOIN users u ON u.id = r.user_id
+	WHERE u.telegram_id = $1`, hostTelegramID)
+	if err != nil {
+		log.Println("GetHostedGuestIDs error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			log.Println("GetHostedGuestIDs scan error:", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// Гости, которых игрок привёл на событие
+func GetGuests(hostTelegramID int64, eventID int) []Guest {
+	rows, err := DB.Query(`
+	SELECT g.id, g.guest_name
+	FROM registrations g
+	JOIN registrations r ON r.id = g.host_registration_id
+	JOIN users u ON u.id = r.user_id
+	WHERE u.telegram_id = $1 AND r.event_id = $2
+	ORDER BY g.id`, hostTelegramID, eventID)
+	if err != nil {
+		log.Println("GetGuests error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var guests []Guest
+	for rows.Next() {
+		var g Guest
+		if err := rows.Scan(&g.RegistrationID, &g.Name); err != nil {
+			log.Println("GetGuests scan error:", err)
+			continue
+		}
+		guests = append(guests, g)
+	}
+	return guests
+}
+
+// Отменить запись гостя. Удалить можно только своего гостя. Возвращает id события
+func RemoveGuest(hostTelegramID int64, guestRegID int) (int, error) {
+	var eventID int
+	err := DB.QueryRow(`
+	DELETE FROM registrations g
+	USING registrations r, users u
+	WHERE g.id = $2 AND r.id = g.host_registration_id AND u.id = r.user_id AND u.telegram_id = $1
+	RETURNING g.event_id`, hostTelegramID, guestRegID).Scan(&eventID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("гость не найден")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("RemoveGuest error: %v", err)
+	}
+	return eventID, nil
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+type InviteCode struct {
+	Code       string
+	EventID    int
+	EventTitle string
+	Uses       int
+	CreatedAt  time.Time
+}
+
+// Запомнить, кто пригласил игрока. Только для тех, кто ещё не закончил регистрацию в боте
+// и не пришёл по другой ссылке
+func SetUserReferrer(telegramID, referrerTelegramID int64) error {
+	_, err := DB.Exec(`
+	UPDATE users u SET referred_by = r.id
+	FROM users r
+	WHERE u.telegram_id = $1 AND r.telegram_id = $2 AND u.id <> r.id
+		AND u.referred_by IS NULL AND u.invite_code IS NULL AND coalesce(u.nickname, '') = ''`, telegramID, referrerTelegramID)
+	if err != nil {
+		return fmt.Errorf("SetUserReferrer error: %v", err)
+	}
+	return nil
+}
+
+// Сколько игроков пришло по ссылке пользователя
+func GetReferralCount(telegramID int64) int {
+	var count int
+	err := DB.QueryRow(`
+	SELECT count(*) FROM users u
+	JOIN users r ON r.id = u.referred_by
+	WHERE r.telegram_id = $1`, telegramID).Scan(&count)
+	if err != nil {
+		log.Println("GetReferralCount error:", err)
+	}
+	return count
+}
+
+func CreateInviteCode(code string, eventID int, createdBy int64) error {
+	_, err := DB.Exec(`INSERT INTO invite_codes (code, event_id, created_by) VALUES ($1, NULLIF($2, 0), $3)`, code, eventID, createdBy)
+	if err != nil {
+		return fmt.Errorf("CreateInviteCode error: %v", err)
+	}
+	return nil
+}
+
+// Применить код приглашения. Новому игроку код засчитывается один раз.
+// Возвращает id события, привязанного к коду (0 — без события)
+func RedeemInviteCode(code string, telegramID int64) (int, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("RedeemInviteCode begin error: %v", err)
+	}
+	defer tx.Rollback()
+
+	var eventID int
+	err = tx.QueryRow(`SELECT coalesce(event_id, 0) FROM invite_codes WHERE code = $1`, code).Scan(&eventID)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("код приглашения не найден")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("RedeemInviteCode select error: %v", err)
+	}
+
+	res, err := tx.Exec(`
+	UPDATE users SET invite_code = $1
+	WHERE telegram_id = $2 AND invite_code IS NULL AND referred_by IS NULL AND coalesce(nickname, '') = ''`, code, telegramID)
+	if err != nil {
+		return 0, fmt.Errorf("RedeemInviteCode update error: %v", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		if _, err := tx.Exec(`UPDATE invite_codes SET uses = uses + 1 WHERE code = $1`, code); err != nil {
+			return 0, fmt.Errorf("RedeemInviteCode uses error: %v", err)
+		}
+	}
+
+	return eventID, tx.Commit()
+}
+
+func GetInviteCodes() []InviteCode {
+	rows, err := DB.Query(`
+	SELECT c.code, coalesce(c.event_id, 0), coalesce(e.title, ''), c.uses, c.created_at
+	FROM invite_codes c
+	LEFT JOIN events e ON e.id = c.event_id
+	ORDER BY c.created_at DESC
+	LIMIT 50`)
+	if err != nil {
+		log.Println("GetInviteCodes error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var codes []InviteCode
+	for rows.Next() {
+		var c InviteCode
+		if err := rows.Scan(&c.Code, &c.EventID, &c.EventTitle, &c.Uses, &c.CreatedAt); err != nil {
+			log.Println("GetInviteCodes scan error:", err)
+			continue
+		}
+		codes = append(codes, c)
+	}
+	return codes
+}
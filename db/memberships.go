@@ -0,0 +1,149 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+type Membership struct {
+	ID            int
+	UserID        int
+	Type          string
+	StartsAt      time.Time
+	EndsAt        time.Time
+	GamesIncluded int
+	GamesUsed     int
+}
+
+// Абонемент вместе с данными владельца для списков и напоминаний
+type MembershipLine struct {
+	Membership
+	ChatID     int64
+	TelegramID int64
+	Name       string
+	Nickname   string
+	Language   string
+}
+
+// Разовый донат за одну игру, €
+const DonationEUR = 5
+
+type PaymentStats struct {
+	ByMembership int
+	ByDonation   int
+}
+
+const membershipColumns = `
+	m.id, m.user_id, m.type, m.starts_at, m.ends_at, m.games_included,
+	(SELECT COUNT(*) FROM registrations r WHERE r.membership_id = m.id)`
+
+// Выдать новый абонемент пользователю
+func CreateMembership(userID int, membershipType string, days int, games int) (Membership, error) {
+	var m Membership
+	q := fmt.Sprintf(`
+	INSERT INTO memberships (user_id, type, ends_at, games_included)
+	VALUES ($1, $2, now() + make_interval(days => $3), $4)
+	RETURNING %s`, membershipColumns)
+	err := DB.QueryRow(q, userID, membershipType, days, games).
+		Scan(&m.ID, &m.UserID, &m.Type, &m.StartsAt, &m.EndsAt, &m.GamesIncluded, &m.GamesUsed)
+	if err != nil {
+		return m, fmt.Errorf("CreateMembership error: %v", err)
+	}
+	return m, nil
+}
+
+// Продлить последний абонемент пользователя на days дней и добавить games игр
+func ExtendMembership(userID int, days int, games int) (Membership, error) {
+	var m Membership
+	q := fmt.Sprintf(`
+	UPDATE memberships m SET
+		ends_at = greatest(m.ends_at, now()) + make_interval(days => $2),
+		games_included = CASE WHEN m.games_included = 0 THEN 0 ELSE m.games_included + $3 END,
+		expiry_reminder_sent = false,
+		updated_at = now()
+	WHERE m.id = (SELECT id FROM memberships WHERE user_id = $1 ORDER BY ends_at DESC LIMIT 1)
+	RETURNING %s`, membershipColumns)
+	err := DB.QueryRow(q, userID, days, games).
+		Scan(&m.ID, &m.UserID, &m.Type, &m.StartsAt, &m.EndsAt, &m.GamesIncluded, &m.GamesUsed)
+	if err != nil {
+		return m, fmt.Errorf("ExtendMembership error: %v", err)
+	}
+	return m, nil
+}
+
+// Действующие абонементы со сведениями о владельцах
+func GetActiveMemberships() []MembershipLine {
+	q := fmt.Sprintf(`
+	SELECT %s, u.chat_id, u.telegram_id, coalesce(u.name, ''), coalesce(u.nickname, ''), coalesce(u.language, '')
+	FROM memberships m
+	JOIN users u ON u.id = m.user_id
+	WHERE m.ends_at >= now()
+	ORDER BY m.ends_at`, membershipColumns)
+	return queryMembershipLines(q)
+}
+
+// Абонементы, которые истекают в ближайшие d и по которым ещё не было напоминания
+func GetExpiringMemberships(d time.Duration) []MembershipLine {
+	q := fmt.Sprintf(`
+	SELECT %s, u.chat_id, u.telegram_id, coalesce(u.name, ''), coalesce(u.nickname, ''), coalesce(u.language, '')
+	FROM memberships m
+	JOIN users u ON u.id = m.user_id
+	WHERE m.ends_at > now() AND m.ends_at <= now() + $1::interval
+	  AND m.expiry_reminder_sent = false
+	  AND u.blocked_at IS NULL
+	ORDER BY m.ends_at`, membershipColumns)
+	return queryMembershipLines(q, fmt.Sprintf("%f hour", d.Hours()))
+}
+
+func queryMembershipLines(q string, args ...any) []MembershipLine {
+	rows, err := DB.Query(q, args...)
+	if err != nil {
+		log.Println("queryMembershipLines error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var lines []MembershipLine
+	for rows.Next() {
+		var l MembershipLine
+		err := rows.Scan(&l.ID, &l.UserID, &l.Type, &l.StartsAt, &l.EndsAt, &l.GamesIncluded, &l.GamesUsed,
+			&l.ChatID, &l.TelegramID, &l.Name, &l.Nickname, &l.Language)
+		if err != nil {
+			log.Println("queryMembershipLines scan error:", err)
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func MarkMembershipReminderSent(membershipID int) error {
+	_, err := DB.Exec(`UPDATE memberships SET expiry_reminder_sent = true WHERE id = $1`, membershipID)
+	return err
+}
+
+// Сколько регистраций на события с начала since покрыто абонементами, а сколько разовыми донатами
+func GetPaymentStats(since time.Time) (PaymentStats, error) {
+	var stats PaymentStats
+	err := DB.QueryRow(`
+	SELECT
+		COUNT(*) FILTER (WHERE r.membership_id IS NOT NULL),
+		COUNT(*) FILTER (WHERE r.membership_id IS NULL)
+	FROM registrations r
+	JOIN events e ON e.id = r.event_id
+	WHERE e.starts_at >= $1 AND e.starts_at < now()`, since).Scan(&stats.ByMembership, &stats.ByDonation)
+	if err != nil {
+		return stats, fmt.Errorf("GetPaymentStats error: %v", err)
+	}
+	return stats, nil
+}
+
+// Последний (по сроку окончания) абонемент пользователя
+func GetLatestMembership(userID int) (Membership, error) {
+	var m Membership
+	q := fmt.Sprintf(`SELECT %s FROM memberships m WHERE m.user_id = $1 ORDER BY m.ends_at DESC LIMIT 1`, membershipColumns)
+	err := DB.QueryRow(q, userID).
+		Scan(&m.ID, &m.UserID, &m.Type, &m.StartsAt, &m.EndsAt, &m.GamesIncluded, &m.GamesUsed)
+	return m, err
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"laverdad-bot/locales"
+
+	"github.com/lib/pq"
+)
+
+// Типы уведомлений. Напоминания и new_week_dm совпадают с колонками user_settings, которыми игрок их включает
+const (
+	KindReminder24 = "reminder24"
+	KindReminder3  = "reminder3"
+	KindReminder1  = "reminder1"
+	KindNewWeek    = "new_week_dm"
+	KindSubscribed = "subscription"
+	KindNoSeat     = "subscription_no_seat"
+)
+
+// За сколько до начала игры отправляется каждое напоминание.
+// Чтобы добавить новое напоминание, достаточно дописать его сюда и добавить текст в бот
+var ReminderSchedule = []struct {
+	Kind   string
+	Before time.Duration
+}{
+	{KindReminder24, 24 * time.Hour},
+	{KindReminder3, 3 * time.Hour},
+	{KindReminder1, time.Hour},
+}
+
+// Уведомление, время которого наступило. У уведомлений без регистрации поля события пустые
+type DueNotification struct {
+	ID            int64
+	Kind          string
+	TelegramID    int64
+	ChatID        int64
+	Blocked       bool
+	Language      string
+	EventID       int
+	EventSeries   string
+	EventTitle    string
+	EventLocation string
+	EventStartsAt time.Time
+}
+
+// Запланировать напоминания для новой регистрации. Напоминания, время которых уже прошло, не создаются
+func scheduleReminders(tx *sql.Tx, regID int64) error {
+	kinds := make([]string, 0, len(ReminderSchedule))
+	secs := make([]int64, 0, len(ReminderSchedule))
+	for _, r := range ReminderSchedule {
+		kinds = append(kinds, r.Kind)
+		secs = append(secs, int64(r.Before/time.Second))
+	}
+
+	_, err := tx.Exec(`
+	INSERT INTO scheduled_notifications (registration_id, kind, send_at)
+	SELECT r.id, k.kind, e.starts_at - make_interval(secs => k.secs)
+	FROM registrations r
+	JOIN events e ON e.id = r.event_id
+	CROSS JOIN unnest($2::text[], $3::bigint[]) AS k(kind, secs)
+	WHERE r.id = $1 AND e.starts_at - make_interval(secs => k.secs) > now()
+	ON CONFLICT (registration_id, kind) DO NOTHING`, regID, pq.Array(kinds), pq.Array(secs))
+	if err != nil {
+		return fmt.Errorf("scheduleReminders error: %v", err)
+	}
+	return nil
+}
+
+// Запланировать игроку уведомление без привязки к регистрации; eventID == 0 — без события.
+// Если такое уведомление уже ждёт отправки, у него меняется время
+func ScheduleUserNotification(telegramID int64, eventID int, kind string, sendAt time.Time) error {
+	_, err := DB.Exec(`
+	INSERT INTO scheduled_notifications (user_id, event_id, kind, send_at)
+	SELECT id, NULLIF($2, 0), $3, $4 FROM users WHERE telegram_id = $1
+	ON CONFLICT (user_id, kind, coalesce(event_id, 0)) WHERE status = 'pending' DO UPDATE SET send_at = EXCLUDED.send_at`,
+		telegramID, eventID, kind, sendAt)
+	if err != nil {
+		return fmt.Errorf("ScheduleUserNotification error: %v", err)
+	}
+	return nil
+}
+
+// Запланировать уведомление по регистрации игрока на событие
+func ScheduleRegistrationNotification(telegramID int64, eventID int, kind string, sendAt time.Time) error {
+	_, err := DB.Exec(`
+	INSERT INTO scheduled_notifications (registration_id, kind, send_at)
+	SELECT r.id, $3, $4 FROM registrations r
+	JOIN users u ON u.id = r.user_id
+	WHERE u.telegram_id = $1 AND r.event_id = $2
+	ON CONFLICT (registration_id, kind) DO NOTHING`, telegramID, eventID, kind, sendAt)
+	if err != nil {
+		return fmt.Errorf("ScheduleRegistrationNotification error: %v", err)
+	}
+	return nil
+}
+
+// Забрать до limit уведомлений, время которых наступило.
+// Строки атомарно переводятся в статус sending, поэтому каждое уведомление забирается ровно один раз,
+// даже если воркеров несколько. Если бот упадёт до отправки, строка останется в sending:
+// лучше потерять напоминание, чем прислать его дважды
+func ClaimDueNotifications(limit int) ([]DueNotification, error) {
+	rows, err := DB.Query(`
+	WITH due AS (
+		UPDATE scheduled_notifications SET status = 'sending', attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM scheduled_notifications
+			WHERE status = 'pending' AND send_at <= now()
+			ORDER BY send_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, registration_id, user_id, event_id, kind
+	)
+	SELECT due.id, due.kind, u.telegram_id, u.chat_id, u.blocked_at IS NOT NULL, coalesce(u.language, ''),
+		coalesce(e.id, 0), coalesce(e.series, ''), coalesce(e.title, ''), coalesce(e.location, ''), e.starts_at
+	FROM due
+	LEFT JOIN registrations r ON r.id = due.registration_id
+	LEFT JOIN events e ON e.id = coalesce(r.event_id, due.event_id)
+	JOIN users u ON u.id = coalesce(r.user_id, due.user_id)`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ClaimDueNotifications error: %v", err)
+	}
+	defer rows.Close()
+
+	var due []DueNotification
+	for rows.Next() {
+		var n DueNotification
+		var startsAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Kind, &n.TelegramID, &n.ChatID, &n.Blocked, &n.Language, &n.EventID, &n.EventSeries, &n.EventTitle, &n.EventLocation, &startsAt); err != nil {
+			log.Println("ClaimDueNotifications scan error:", err)
+			continue
+		}
+		if startsAt.Valid {
+			n.EventStartsAt = locales.ClubTime(startsAt.Time)
+		}
+		due = append(due, n)
+	}
+	return due, nil
+}
+
+func MarkNotificationSent(id int64) error {
+	_, err := DB.Exec(`UPDATE scheduled_notifications SET status = 'sent', sent_at = now(), error = NULL WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("MarkNotificationSent error: %v", err)
+	}
+	return nil
+}
+
+func MarkNotificationFailed(id int64, reason error) error {
+	_, err := DB.Exec(`UPDATE scheduled_notifications SET status = 'failed', error = $2 WHERE id = $1`, id, reason.Error())
+	if err != nil {
+		return fmt.Errorf("MarkNotificationFailed error: %v", err)
+	}
+	return nil
+}
+
+// Уведомление не нужно: игрок отключил его, заблокировал бота или игра уже началась
+func SkipNotification(id int64, reason string) error {
+	_, err := DB.Exec(`UPDATE scheduled_notifications SET status = 'skipped', error = $2 WHERE id = $1`, id, reason)
+	if err != nil {
+		return fmt.Errorf("SkipNotification error: %v", err)
+	}
+	return nil
+}
+
+// Вернуть уведомление в очередь с новым временем отправки (например, после тихих часов)
+func RescheduleNotification(id int64, sendAt time.Time) error {
+	_, err := DB.Exec(`UPDATE scheduled_notifications SET status = 'pending', send_at = $2 WHERE id = $1`, id, sendAt)
+	if err != nil {
+		return fmt.Errorf("RescheduleNotification error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,442 @@
+package db
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Выгрузка персональных данных пользователя (GDPR, право на доступ)
+type PersonalData struct {
+	User          PersonalUser           `json:"user"`
+	Settings      PersonalSettings       `json:"settings"`
+	Registrations []PersonalRegistration `json:"registrations"`
+	Guests        []PersonalGuest        `json:"guests"`
+	Transfers     []PersonalTransfer     `json:"transfers"`
+	Subscriptions []PersonalSubscription `json:"subscriptions"`
+	Memberships   []PersonalMembership   `json:"memberships"`
+	ExportedAt    time.Time              `json:"exported_at"`
+}
+
+type PersonalUser struct {
+	ID         int        `json:"id"`
+	TelegramID int64      `json:"telegram_id"`
+	ChatID     int64      `json:"chat_id"`
+	UserName   string     `json:"username"`
+	Name       string     `json:"name"`
+	Nickname   string     `json:"nickname"`
+	Phone      string     `json:"phone"`
+	Language   string     `json:"language"`
+	ReferredBy *int       `json:"referred_by_user_id"`
+	InviteCode string     `json:"invite_code"`
+	BlockedAt  *time.Time `json:"blocked_bot_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type PersonalSettings struct {
+	Reminder24 bool   `json:"reminder_24h"`
+	Reminder3  bool   `json:"reminder_3h"`
+	Reminder1  bool   `json:"reminder_1h"`
+	NewWeekDM  bool   `json:"new_week_dm"`
+	QuietFrom  *int16 `json:"quiet_from"`
+	QuietTo    *int16 `json:"quiet_to"`
+}
+
+// Гость, которого игрок привёл на игру
+type PersonalGuest struct {
+	EventID    int       `json:"event_id"`
+	EventTitle string    `json:"event_title"`
+	Name       string    `json:"name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type PersonalTransfer struct {
+	EventID    int        `json:"event_id"`
+	EventTitle string     `json:"event_title"`
+	Direction  string     `json:"direction"` // sent | received
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+}
+
+type PersonalSubscription struct {
+	Series    string    `json:"series"`
+	Paused    bool      `json:"paused"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PersonalRegistration struct {
+	ID            int                `json:"id"`
+	EventID       int                `json:"event_id"`
+	EventTitle    string             `json:"event_title"`
+	EventStartsAt time.Time          `json:"event_starts_at"`
+	Status        string             `json:"status"`
+	PaidBy        string             `json:"paid_by"`
+	CreatedAt     time.Time          `json:"created_at"`
+	Reminders     []PersonalReminder `json:"reminders"`
+}
+
+type PersonalReminder struct {
+	Kind   string    `json:"kind"`
+	SendAt time.Time `json:"send_at"`
+	Status string    `json:"status"`
+}
+
+type PersonalMembership struct {
+	ID            int       `json:"id"`
+	Type          string    `json:"type"`
+	StartsAt      time.Time `json:"starts_at"`
+	EndsAt        time.Time `json:"ends_at"`
+	GamesIncluded int       `json:"games_included"`
+	GamesUsed     int       `json:"games_used"`
+}
+
+func GetPersonalData(telegramID int64) (PersonalData, error) {
+	data := PersonalData{ExportedAt: time.Now()}
+
+	u := &data.User
+	err := DB.QueryRow(`
+	SELECT id, telegram_id, chat_id, coalesce(username, ''), coalesce(name, ''), coalesce(nickname, ''), coalesce(phone, ''),
+		coalesce(language, ''), referred_by, coalesce(invite_code, ''), blocked_at, created_at, updated_at
+	FROM users WHERE telegram_id=$1`, telegramID).
+		Scan(&u.ID, &u.TelegramID, &u.ChatID, &u.UserName, &u.Name, &u.Nickname, &u.Phone,
+			&u.Language, &u.ReferredBy, &u.InviteCode, &u.BlockedAt, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return data, fmt.Errorf("GetPersonalData user error: %v", err)
+	}
+
+	s := GetUserSettings(telegramID)
+	data.Settings = PersonalSettings{Reminder24: s.Reminder24, Reminder3: s.Reminder3, Reminder1: s.Reminder1, NewWeekDM: s.NewWeekDM}
+	if s.QuietFrom.Valid && s.QuietTo.Valid {
+		data.Settings.QuietFrom, data.Settings.QuietTo = &s.QuietFrom.Int16, &s.QuietTo.Int16
+	}
+
+	rows, err := DB.Query(`
+	SELECT r.id, e.id, e.title, e.starts_at, r.status, r.membership_id IS NOT NULL, r.created_at
+	FROM registrations r
+	JOIN events e ON e.id = r.event_id
+	WHERE r.user_id = $1
+	ORDER BY e.starts_at`, u.ID)
+	if err != nil {
+		return data, fmt.Errorf("GetPersonalData registrations error: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r PersonalRegistration
+		var byMembership bool
+		err := rows.Scan(&r.ID, &r.EventID, &r.EventTitle, &r.EventStartsAt, &r.Status, &byMembership, &r.CreatedAt)
+		if err != nil {
+			return data, fmt.Errorf("GetPersonalData registrations scan error: %v", err)
+		}
+		r.PaidBy = "donation"
+		if byMembership {
+			r.PaidBy = "membership"
+		}
+		data.Registrations = append(data.Registrations, r)
+	}
+
+	nRows, err := DB.Query(`
+	SELECT n.registration_id, n.kind, n.send_at, n.status
+	FROM scheduled_notifications n
+	JOIN registrations r ON r.id = n.registration_id
+	WHERE r.user_id = $1
+	ORDER BY n.send_at`, u.ID)
+	if err != nil {
+		return data, fmt.Errorf("GetPersonalData reminders error: %v", err)
+	}
+	defer nRows.Close()
+	for nRows.Next() {
+		var regID int
+		var n PersonalReminder
+		if err := nRows.Scan(&regID, &n.Kind, &n.SendAt, &n.Status); err != nil {
+			return data, fmt.Errorf("GetPersonalData reminders scan error: %v", err)
+		}
+		for i := range data.Registrations {
+			if data.Registrations[i].ID == regID {
+				data.Registrations[i].Reminders = append(data.Registrations[i].Reminders, n)
+			}
+		}
+	}
+
+	gRows, err := DB.Query(`
+	SELECT e.id, e.title, g.guest_name, g.created_at
+	FROM registrations g
+	JOIN registrations r ON r.id = g.host_registration_id
+	JOIN events e ON e.id = g.event_id
+	WHERE r.user_id = $1
+	ORDER BY e.starts_at`, u.ID)
+	if err != nil {
+		return data, fmt.Errorf("GetPersonalData guests error: %v", err)
+	}
+	defer gRows.Close()
+	for gRows.Next() {
+		var g PersonalGuest
+		if err := gRows.Scan(&g.EventID, &g.EventTitle, &g.Name, &g.CreatedAt); err != nil {
+			return data, fmt.Errorf("GetPersonalData guests scan error: %v", err)
+		}
+		data.Guests = append(data.Guests, g)
+	}
+
+	tRows, err := DB.Query(`
+	SELECT e.id, e.title, CASE WHEN t.from_user_id = $1 THEN 'sent' ELSE 'received' END, t.status, t.created_at, t.resolved_at
+	FROM registration_transfers t
+	JOIN events e ON e.id = t.event_id
+	WHERE t.from_user_id = $1 OR t.to_user_id = $1
+	ORDER BY t.created_at`, u.ID)
+	if err != nil {
+		return data, fmt.Errorf("GetPersonalData transfers error: %v", err)
+	}
+	defer tRows.Close()
+	for tRows.Next() {
+		var t PersonalTransfer
+		if err := tRows.Scan(&t.EventID, &t.EventTitle, &t.Direction, &t.Status, &t.CreatedAt, &t.ResolvedAt); err != nil {
+			return data, fmt.Errorf("GetPersonalData transfers scan error: %v", err)
+		}
+		data.Transfers = append(data.Transfers, t)
+	}
+
+	sRows, err := DB.Query(`SELECT series, paused, created_at FROM series_subscriptions WHERE user_id = $1 ORDER BY created_at`, u.ID)
+	if err != nil {
+		return data, fmt.Errorf("GetPersonalData subscriptions error: %v", err)
+	}
+	defer sRows.Close()
+	for sRows.Next() {
+		var s PersonalSubscription
+		if err := sRows.Scan(&s.Series, &s.Paused, &s.CreatedAt); err != nil {
+			return data, fmt.Errorf("GetPersonalData subscriptions scan error: %v", err)
+		}
+		data.Subscriptions = append(data.Subscriptions, s)
+	}
+
+	mRows, err := DB.Query(fmt.Sprintf(`SELECT %s FROM memberships m WHERE m.user_id = $1 ORDER BY m.starts_at`, membershipColumns), u.ID)
+	if err != nil {
+		return data, fmt.Errorf("GetPersonalData memberships error: %v", err)
+	}
+	defer mRows.Close()
+	for mRows.Next() {
+		var m PersonalMembership
+		var userID int
+		err := mRows.Scan(&m.ID, &userID, &m.Type, &m.StartsAt, &m.EndsAt, &m.GamesIncluded, &m.GamesUsed)
+		if err != nil {
+			return data, fmt.Errorf("GetPersonalData memberships scan error: %v", err)
+		}
+		data.Memberships = append(data.Memberships, m)
+	}
+
+	return data, nil
+}
+
+// Обезличить пользователя (GDPR, право на забвение).
+// Строка в users остаётся, чтобы регистрации и статистика не потерялись,
+// но все персональные данные удаляются, а telegram_id заменяется отрицательным id.
+// Тем же псевдонимом заменяется telegram_id во всех таблицах, где он хранится без ссылки на users,
+// в том числе в журнале действий администраторов; из before/after журнала убираются и имя, ник, username и телефон.
+// Строки Google Sheets обезличивает вызывающий код.
+func ForgetUser(telegramID int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("ForgetUser begin error: %v", err)
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var userName, name, nickname, phone string
+	err = tx.QueryRow(`
+	SELECT id, coalesce(username, ''), coalesce(name, ''), coalesce(nickname, ''), coalesce(phone, '')
+	FROM users WHERE telegram_id=$1`, telegramID).Scan(&userID, &userName, &name, &nickname, &phone)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("пользователь не найден")
+	}
+	if err != nil {
+		return fmt.Errorf("ForgetUser select error: %v", err)
+	}
+
+	_, err = tx.Exec(`
+	UPDATE users SET
+		telegram_id = -id,
+		chat_id = 0,
+		username = NULL,
+		name = 'Удалённый игрок',
+		nickname = NULL,
+		phone = NULL,
+		language = NULL,
+		referred_by = NULL,
+		invite_code = NULL,
+		blocked_at = NULL,
+		updated_at = now()
+	WHERE id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("ForgetUser update error: %v", err)
+	}
+
+	// Имена гостей — тоже персональные данные, а привести их мог только этот игрок
+	_, err = tx.Exec(`
+	UPDATE registrations SET guest_name = 'Гость'
+	WHERE host_registration_id IN (SELECT id FROM registrations WHERE user_id = $1)`, userID)
+	if err != nil {
+		return fmt.Errorf("ForgetUser guests error: %v", err)
+	}
+
+	pseudonym := -int64(userID)
+	cleanup := []struct {
+		name  string
+		query string
+		args  []any
+	}{
+		{"settings", `DELETE FROM user_settings WHERE user_id = $1`, []any{userID}},
+		{"subscriptions", `DELETE FROM series_subscriptions WHERE user_id = $1`, []any{userID}},
+		{"roles", `DELETE FROM user_roles WHERE telegram_id = $1`, []any{telegramID}},
+		{"deliveries", `DELETE FROM delivery_failures WHERE chat_id = $1`, []any{telegramID}},
+		{"granted roles", `UPDATE user_roles SET granted_by = $2 WHERE granted_by = $1`, []any{telegramID, pseudonym}},
+		{"invite codes", `UPDATE invite_codes SET created_by = $2 WHERE created_by = $1`, []any{telegramID, pseudonym}},
+		{"registered by", `UPDATE registrations SET registered_by = $2 WHERE registered_by = $1`, []any{telegramID, pseudonym}},
+	}
+	for _, c := range cleanup {
+		if _, err := tx.Exec(c.query, c.args...); err != nil {
+			return fmt.Errorf("ForgetUser %s error: %v", c.name, err)
+		}
+	}
+
+	// Журнал только дополняется; правило в 19_audit_forget.sql пропускает изменение,
+	// только когда в транзакции задан laverdad.forget_user
+	_, err = tx.Exec(`SELECT set_config('laverdad.forget_user', $1, true)`, fmt.Sprint(userID))
+	if err != nil {
+		return fmt.Errorf("ForgetUser audit config error: %v", err)
+	}
+	_, err = tx.Exec(`
+	UPDATE admin_audit SET
+		actor_telegram_id = CASE WHEN actor_telegram_id = $1 THEN $2 ELSE actor_telegram_id END,
+		target = CASE WHEN target = $3 THEN $4 ELSE target END
+	WHERE actor_telegram_id = $1 OR target = $3`, telegramID, pseudonym, fmt.Sprint(telegramID), fmt.Sprint(pseudonym))
+	if err != nil {
+		return fmt.Errorf("ForgetUser audit error: %v", err)
+	}
+	replace := map[string]string{name: "Удалённый игрок", nickname: "", userName: "", "@" + userName: "", phone: ""}
+	delete(replace, "")
+	delete(replace, "@")
+	if err := scrubAudit(tx, telegramID, pseudonym, replace); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+	UPDATE scheduled_notifications SET status = 'cancelled'
+	WHERE status = 'pending' AND (user_id = $1 OR registration_id IN (SELECT id FROM registrations WHERE user_id = $1))`, userID)
+	if err != nil {
+		return fmt.Errorf("ForgetUser notifications error: %v", err)
+	}
+
+	return tx.Commit()
+}
+
+// Убрать данные игрока из before/after журнала: telegram_id заменяется псевдонимом,
+// а имя, ник, username и телефон — по словарю replace. Заменяются только значения целиком,
+// поэтому чужой текст, в котором случайно встретилось имя, не портится
+func scrubAudit(tx *sql.Tx, telegramID, pseudonym int64, replace map[string]string) error {
+	patterns := []string{"%" + strconv.FormatInt(telegramID, 10) + "%"}
+	for value := range replace {
+		patterns = append(patterns, "%"+value+"%")
+	}
+
+	type record struct {
+		id            int64
+		before, after []byte
+	}
+	rows, err := tx.Query(`
+	SELECT id, before, after FROM admin_audit
+	WHERE before::text LIKE ANY($1) OR after::text LIKE ANY($1)`, pq.Array(patterns))
+	if err != nil {
+		return fmt.Errorf("ForgetUser audit payload error: %v", err)
+	}
+	var records []record
+	for rows.Next() {
+		var r record
+		if err := rows.Scan(&r.id, &r.before, &r.after); err != nil {
+			rows.Close()
+			return fmt.Errorf("ForgetUser audit payload scan error: %v", err)
+		}
+		records = append(records, r)
+	}
+	rows.Close()
+
+	for _, r := range records {
+		before, changedBefore, err := scrubAuditJSON(r.before, telegramID, pseudonym, replace)
+		if err != nil {
+			return fmt.Errorf("ForgetUser audit payload %d error: %v", r.id, err)
+		}
+		after, changedAfter, err := scrubAuditJSON(r.after, telegramID, pseudonym, replace)
+		if err != nil {
+			return fmt.Errorf("ForgetUser audit payload %d error: %v", r.id, err)
+		}
+		if !changedBefore && !changedAfter {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE admin_audit SET before = $2, after = $3 WHERE id = $1`, r.id, jsonParam(before), jsonParam(after)); err != nil {
+			return fmt.Errorf("ForgetUser audit payload update error: %v", err)
+		}
+	}
+	return nil
+}
+
+// Заменить в JSON значения, совпадающие с telegram_id или с ключами replace. NULL возвращается как есть
+func scrubAuditJSON(data []byte, telegramID, pseudonym int64, replace map[string]string) ([]byte, bool, error) {
+	if data == nil {
+		return nil, false, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return data, false, err
+	}
+
+	id, alias := strconv.FormatInt(telegramID, 10), strconv.FormatInt(pseudonym, 10)
+	changed := false
+	var walk func(any) any
+	walk = func(v any) any {
+		switch x := v.(type) {
+		case map[string]any:
+			for k, item := range x {
+				x[k] = walk(item)
+			}
+		case []any:
+			for i, item := range x {
+				x[i] = walk(item)
+			}
+		case json.Number:
+			if string(x) == id {
+				changed = true
+				return json.Number(alias)
+			}
+		case string:
+			if x == id {
+				changed = true
+				return alias
+			}
+			if r, ok := replace[x]; ok {
+				changed = true
+				return r
+			}
+		}
+		return v
+	}
+	v = walk(v)
+	if !changed {
+		return data, false, nil
+	}
+	out, err := json.Marshal(v)
+	return out, true, err
+}
+
+// jsonb передаётся строкой: []byte lib/pq отправил бы как bytea
+func jsonParam(b []byte) any {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestScrubAuditJSON(t *testing.T) {
+	replace := map[string]string{"Ана": "Удалённый игрок", "ana_m": "", "@ana": ""}
+
+	for _, tc := range []struct {
+		name, in, want string
+		changed        bool
+	}{
+		{"null", "", "", false},
+		{"unrelated", `{"role":"admin","text":"Ана пришла"}`, `{"role":"admin","text":"Ана пришла"}`, false},
+		{"event id", `42`, `42`, false},
+		{"telegram id", `{"TelegramID":5001,"Chat":[5001,"5001"]}`, `{"TelegramID":-17,"Chat":[-17,"-17"]}`, true},
+		{"personal values", `{"Name":"Ана","Nickname":"ana_m","Players":["@ana","Хуан"]}`, `{"Name":"Удалённый игрок","Nickname":"","Players":["","Хуан"]}`, true},
+		{"big numbers stay exact", `{"ID":12345678901234567,"TelegramID":5001}`, `{"ID":12345678901234567,"TelegramID":-17}`, true},
+	} {
+		var in []byte
+		if tc.in != "" {
+			in = []byte(tc.in)
+		}
+		out, changed, err := scrubAuditJSON(in, 5001, -17, replace)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if changed != tc.changed {
+			t.Errorf("%s: changed = %v, want %v", tc.name, changed, tc.changed)
+		}
+		if tc.want == "" {
+			if out != nil {
+				t.Errorf("%s: got %s, want NULL", tc.name, out)
+			}
+			continue
+		}
+		var got, want any
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		json.Unmarshal([]byte(tc.want), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %s, want %s", tc.name, out, tc.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+type UserRole struct {
+	TelegramID int64
+	Role       string
+	Name       string
+	Nickname   string
+	GrantedBy  int64
+	CreatedAt  time.Time
+}
+
+func GetUserRoles(telegramID int64) []string {
+	rows, err := DB.Query(`SELECT role FROM user_roles WHERE telegram_id=$1`, telegramID)
+	if err != nil {
+		log.Println("GetUserRoles error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			log.Println("GetUserRoles scan error:", err)
+			continue
+		}
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+func GrantRole(telegramID int64, role string, grantedBy int64) error {
+	_, err := DB.Exec(`
+	INSERT INTO user_roles (telegram_id, role, granted_by) VALUES ($1, $2, $3)
+	ON CONFLICT (telegram_id, role) DO NOTHING`, telegramID, role, grantedBy)
+	if err != nil {
+		return fmt.Errorf("GrantRole error: %v", err)
+	}
+	return nil
+}
+
+func RevokeRole(telegramID int64, role string) (bool, error) {
+	res, err := DB.Exec(`DELETE FROM user_roles WHERE telegram_id=$1 AND role=$2`, telegramID, role)
+	if err != nil {
+		return false, fmt.Errorf("RevokeRole error: %v", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func CountRoleHolders(role string) int {
+	var count int
+	err := DB.QueryRow(`SELECT COUNT(*) FROM user_roles WHERE role=$1`, role).Scan(&count)
+	if err != nil {
+		log.Println("CountRoleHolders error:", err)
+	}
+	return count
+}
+
+// Все выданные роли с именами пользователей (если они есть в users)
+func GetAllRoles() []UserRole {
+	rows, err := DB.Query(`
+	SELECT ur.telegram_id, ur.role, coalesce(u.name, ''), coalesce(u.nickname, ''), coalesce(ur.granted_by, 0), ur.created_at
+	FROM user_roles ur
+	LEFT JOIN users u ON u.telegram_id = ur.telegram_id
+	ORDER BY ur.role, ur.created_at`)
+	if err != nil {
+		log.Println("GetAllRoles error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var roles []UserRole
+	for rows.Next() {
+		var r UserRole
+		if err := rows.Scan(&r.TelegramID, &r.Role, &r.Name, &r.Nickname, &r.GrantedBy, &r.CreatedAt); err != nil {
+			log.Println("GetAllRoles scan error:", err)
+			continue
+		}
+		roles = append(roles, r)
+	}
+	return roles
+}
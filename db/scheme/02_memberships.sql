@@ -0,0 +1,19 @@
+-- 02_memberships.sql
+create table if not exists memberships (
+  id bigserial primary key,
+  user_id bigint not null references users(id) on delete cascade,
+  type text not null, -- monthly | season | ...
+  starts_at timestamptz not null default now(),
+  ends_at timestamptz not null,
+  games_included integer not null default 0, -- 0 = без ограничений
+  expiry_reminder_sent boolean not null default false,
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now()
+);
+
+create index if not exists idx_memberships_user_id on memberships(user_id);
+create index if not exists idx_memberships_ends_at on memberships(ends_at);
+
+-- Регистрация, покрытая абонементом; NULL = разовый донат
+ALTER TABLE registrations
+    ADD COLUMN membership_id BIGINT REFERENCES memberships(id) ON DELETE SET NULL;
@@ -0,0 +1,16 @@
+-- 03_nickname_unique.sql
+
+-- Отчёт о существующих дубликатах ников (без учёта регистра).
+-- Дубликаты нужно разрешить вручную (например, через /nick_duplicates в боте)
+-- до создания уникального индекса ниже.
+SELECT lower(nickname) AS nickname, COUNT(*) AS users_count,
+       string_agg(format('id=%s tg=%s name=%s', id, telegram_id, coalesce(name, '')), '; ' ORDER BY id) AS users
+FROM users
+WHERE nickname IS NOT NULL AND nickname <> ''
+GROUP BY lower(nickname)
+HAVING COUNT(*) > 1
+ORDER BY users_count DESC;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_nickname_lower_idx
+ON users(lower(nickname))
+WHERE nickname IS NOT NULL AND nickname <> '';
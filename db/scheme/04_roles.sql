@@ -0,0 +1,16 @@
+-- 04_roles.sql
+create table if not exists user_roles (
+  telegram_id bigint not null,
+  role text not null, -- owner | admin | host | treasurer
+  granted_by bigint,
+  created_at timestamptz not null default now(),
+  primary key (telegram_id, role)
+);
+
+-- Бывшие администраторы из adminIDs становятся владельцами
+insert into user_roles (telegram_id, role)
+values
+ (115775166, 'owner'),
+ (107463316, 'owner'),
+ (102833932, 'owner')
+on conflict do nothing;
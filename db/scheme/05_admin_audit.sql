@@ -0,0 +1,17 @@
+-- 05_admin_audit.sql
+create table if not exists admin_audit (
+  id bigserial primary key,
+  actor_telegram_id bigint not null,
+  action text not null,
+  target text not null default '',
+  before jsonb,
+  after jsonb,
+  created_at timestamptz not null default now()
+);
+
+create index if not exists idx_admin_audit_created_at on admin_audit(created_at);
+create index if not exists idx_admin_audit_actor on admin_audit(actor_telegram_id);
+
+-- Журнал только дополняется: изменение и удаление записей запрещены
+CREATE OR REPLACE RULE admin_audit_no_update AS ON UPDATE TO admin_audit DO INSTEAD NOTHING;
+CREATE OR REPLACE RULE admin_audit_no_delete AS ON DELETE TO admin_audit DO INSTEAD NOTHING;
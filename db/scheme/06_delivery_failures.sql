@@ -0,0 +1,11 @@
+-- 06_delivery_failures.sql
+-- Сообщения, которые не удалось доставить (например, пользователь заблокировал бота)
+create table if not exists delivery_failures (
+  id bigserial primary key,
+  chat_id bigint not null,
+  error_code integer not null,
+  description text not null,
+  created_at timestamptz not null default now()
+);
+
+create index if not exists idx_delivery_failures_chat_id on delivery_failures(chat_id);
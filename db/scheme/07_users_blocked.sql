@@ -0,0 +1,4 @@
+-- 07_users_blocked.sql
+-- Время, когда пользователь заблокировал бота; NULL — сообщения доставляются
+ALTER TABLE users
+    ADD COLUMN blocked_at TIMESTAMPTZ;
@@ -0,0 +1,11 @@
+-- 08_user_settings.sql
+create table if not exists user_settings (
+  user_id bigint primary key references users(id) on delete cascade,
+  reminder24 boolean not null default true,
+  reminder3 boolean not null default false,
+  reminder1 boolean not null default true,
+  new_week_dm boolean not null default false,
+  quiet_from smallint, -- час начала тихих часов (0-23), NULL — тихих часов нет
+  quiet_to smallint,   -- час окончания тихих часов (0-23)
+  updated_at timestamptz not null default now()
+);
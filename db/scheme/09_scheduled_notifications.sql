@@ -0,0 +1,34 @@
+-- 09_scheduled_notifications.sql
+-- Очередь персональных уведомлений: по строке на каждое напоминание каждой регистрации.
+-- Новые типы напоминаний добавляются в коде (db.ReminderSchedule) без изменения схемы.
+create table if not exists scheduled_notifications (
+  id bigserial primary key,
+  registration_id bigint not null references registrations(id) on delete cascade,
+  kind text not null, -- reminder24 | reminder3 | reminder1
+  send_at timestamptz not null,
+  status text not null default 'pending', -- pending | sending | sent | skipped | failed | cancelled
+  attempts int not null default 0,
+  error text,
+  created_at timestamptz not null default now(),
+  sent_at timestamptz,
+  unique(registration_id, kind)
+);
+
+create index if not exists scheduled_notifications_due_idx
+  on scheduled_notifications(send_at) where status = 'pending';
+
+-- Переносим ещё не отправленные напоминания по будущим играм.
+-- Напоминание за 3 часа раньше не отправлялось, его планирует db.ReminderSchedule для новых регистраций
+insert into scheduled_notifications (registration_id, kind, send_at)
+select r.id, 'reminder24', e.starts_at - interval '24 hours'
+from registrations r join events e on e.id = r.event_id
+where not r.reminder24_sent and e.starts_at - interval '24 hours' > now()
+union all
+select r.id, 'reminder1', e.starts_at - interval '1 hour'
+from registrations r join events e on e.id = r.event_id
+where not r.reminder1_sent and e.starts_at - interval '1 hour' > now()
+on conflict do nothing;
+
+ALTER TABLE registrations
+    DROP COLUMN reminder24_sent,
+    DROP COLUMN reminder1_sent;
@@ -0,0 +1,20 @@
+-- 10_events_timestamptz.sql
+-- InitDB создавал events.starts_at как TIMESTAMP без часового пояса.
+-- Сохранённые значения — время по часам клуба, поэтому переводим их из Europe/Madrid.
+DO $$
+BEGIN
+  IF (SELECT data_type FROM information_schema.columns
+      WHERE table_name = 'events' AND column_name = 'starts_at') = 'timestamp without time zone' THEN
+    ALTER TABLE events ALTER COLUMN starts_at TYPE timestamptz USING starts_at AT TIME ZONE 'Europe/Madrid';
+  END IF;
+END $$;
+
+-- Напоминания, запланированные по неверно прочитанному времени, пересчитываем
+UPDATE scheduled_notifications n
+SET send_at = e.starts_at - (CASE n.kind
+    WHEN 'reminder24' THEN interval '24 hours'
+    WHEN 'reminder3' THEN interval '3 hours'
+    ELSE interval '1 hour' END)
+FROM registrations r
+JOIN events e ON e.id = r.event_id
+WHERE r.id = n.registration_id AND n.status = 'pending';
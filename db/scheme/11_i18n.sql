@@ -0,0 +1,8 @@
+-- 11_i18n.sql
+-- Язык игрока: ru | es | en. Пока бот был только на русском, поэтому существующим игрокам ставим ru,
+-- а новым язык определяется по language_code из Telegram
+ALTER TABLE users ADD COLUMN language text;
+UPDATE users SET language = 'ru' WHERE language IS NULL;
+
+-- Переводы описания события: {"es": "...", "en": "..."}. Основное описание (description) — на языке клуба
+ALTER TABLE events ADD COLUMN description_i18n jsonb NOT NULL DEFAULT '{}';
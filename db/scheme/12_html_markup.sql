@@ -0,0 +1,17 @@
+-- 12_html_markup.sql
+-- Сообщения бота переведены с Markdown на HTML-разметку. Описания и места уже созданных событий
+-- были написаны в Markdown: экранируем в них & < > и переводим ссылки [текст](url) и *жирный* в HTML
+CREATE FUNCTION pg_temp.markdown_to_html(s text) RETURNS text AS $$
+    SELECT regexp_replace(
+        regexp_replace(
+            replace(replace(replace(s, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+            '\[([^\]]+)\]\(([^)]+)\)', '<a href="\2">\1</a>', 'g'),
+        '\*([^*\n]+)\*', '<b>\1</b>', 'g')
+$$ LANGUAGE sql IMMUTABLE;
+
+UPDATE events SET
+    description = pg_temp.markdown_to_html(description),
+    location = pg_temp.markdown_to_html(location),
+    description_i18n = COALESCE(
+        (SELECT jsonb_object_agg(key, pg_temp.markdown_to_html(value)) FROM jsonb_each_text(description_i18n)),
+        '{}');
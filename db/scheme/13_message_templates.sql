@@ -0,0 +1,11 @@
+-- 13_message_templates.sql
+-- Тексты сообщений, изменённые администраторами через /template_set.
+-- Если строки нет, используется текст по умолчанию из кода (пакет render)
+create table if not exists message_templates (
+  name text not null,
+  language text not null,
+  body text not null,
+  updated_by bigint,
+  updated_at timestamptz not null default now(),
+  primary key (name, language)
+);
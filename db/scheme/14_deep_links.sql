@@ -0,0 +1,12 @@
+-- 14_deep_links.sql
+-- Ссылки t.me/<бот>?start=...: ref_<telegram_id> — приглашение от игрока, inv_<код> — код приглашения от клуба
+ALTER TABLE users ADD COLUMN referred_by bigint references users(id) on delete set null;
+ALTER TABLE users ADD COLUMN invite_code text;
+
+create table if not exists invite_codes (
+  code text primary key,
+  event_id bigint references events(id) on delete set null, -- событие, которое откроется по ссылке
+  created_by bigint not null,                                -- telegram_id администратора
+  uses int not null default 0,                               -- сколько новых игроков пришло по коду
+  created_at timestamptz not null default now()
+);
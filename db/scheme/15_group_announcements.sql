@@ -0,0 +1,11 @@
+-- 15_group_announcements.sql
+-- Анонсы недели в группе клуба с кнопками записи. Сообщение редактируется, когда меняется число записавшихся
+create table if not exists group_announcements (
+  id bigserial primary key,
+  chat_id bigint not null,
+  message_id bigint not null,
+  event_ids bigint[] not null,
+  created_at timestamptz not null default now()
+);
+
+create index if not exists idx_group_announcements_event_ids on group_announcements using gin (event_ids);
@@ -0,0 +1,12 @@
+-- 16_guests.sql
+-- Регистрации людей без Telegram: гость игрока (+1) и человек, которого записал администратор.
+-- У таких регистраций нет user_id, вместо него — имя. Гости удаляются вместе с регистрацией игрока
+ALTER TABLE registrations ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE registrations
+    ADD COLUMN guest_name TEXT,
+    ADD COLUMN host_registration_id BIGINT REFERENCES registrations(id) ON DELETE CASCADE,
+    ADD COLUMN registered_by BIGINT; -- telegram_id администратора, если записал он
+ALTER TABLE registrations
+    ADD CONSTRAINT registrations_user_or_guest CHECK (user_id IS NOT NULL OR guest_name IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS idx_registrations_host ON registrations(host_registration_id);
@@ -0,0 +1,14 @@
+-- 17_registration_transfers.sql
+-- Передача места на игре другому игроку. Регистрация остаётся той же (тот же id и строка в таблице),
+-- меняется только её владелец; здесь хранится история передач
+create table if not exists registration_transfers (
+  id bigserial primary key,
+  registration_id bigint not null references registrations(id) on delete cascade,
+  from_user_id bigint not null references users(id),
+  to_user_id bigint not null references users(id),
+  status text not null default 'pending', -- pending | accepted | declined | cancelled
+  created_at timestamptz not null default now(),
+  resolved_at timestamptz
+);
+
+create index if not exists idx_registration_transfers_registration on registration_transfers(registration_id);
@@ -0,0 +1,15 @@
+-- 18_series_subscriptions.sql
+-- Постоянная запись на регулярные игры: подписчиков серии записывают на каждую новую игру недели.
+-- series — ключ серии из services.WeeklySeries (friday, saturday, sunday); у событий, созданных вручную, пусто
+ALTER TABLE events ADD COLUMN IF NOT EXISTS series TEXT;
+
+create table if not exists series_subscriptions (
+  user_id bigint not null references users(id) on delete cascade,
+  series text not null,
+  paused boolean not null default false,
+  created_at timestamptz not null default now(),
+  updated_at timestamptz not null default now(),
+  primary key (user_id, series)
+);
+
+create index if not exists idx_series_subscriptions_series on series_subscriptions(series, created_at) where not paused;
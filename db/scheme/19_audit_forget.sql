@@ -0,0 +1,6 @@
+-- 19_audit_forget.sql
+-- Журнал по-прежнему только дополняется, но /forget_me должен заменить в нём telegram_id игрока псевдонимом.
+-- Изменение разрешено лишь в транзакции, где db.ForgetUser задал laverdad.forget_user; удаление запрещено всегда
+CREATE OR REPLACE RULE admin_audit_no_update AS ON UPDATE TO admin_audit
+  WHERE coalesce(current_setting('laverdad.forget_user', true), '') = ''
+  DO INSTEAD NOTHING;
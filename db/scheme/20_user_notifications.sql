@@ -0,0 +1,11 @@
+-- 20_user_notifications.sql
+-- Уведомления, не привязанные к регистрации: например, сообщение о новой неделе,
+-- отложенное до конца тихих часов игрока
+alter table scheduled_notifications
+  alter column registration_id drop not null,
+  add column if not exists user_id bigint references users(id) on delete cascade,
+  add constraint scheduled_notifications_target check ((registration_id is null) <> (user_id is null));
+
+-- Одно ожидающее уведомление каждого типа на игрока
+create unique index if not exists scheduled_notifications_user_pending_idx
+  on scheduled_notifications(user_id, kind) where status = 'pending';
@@ -0,0 +1,14 @@
+-- 21_series_generation.sql
+-- Повторный /generate не создаёт вторую игру серии на то же время.
+-- Если индекс не создаётся, в базе уже есть дубли:
+--   select series, starts_at, count(*) from events where series is not null group by 1, 2 having count(*) > 1;
+create unique index if not exists events_series_starts_at_idx
+  on events(series, starts_at) where series is not null;
+
+-- Уведомление игроку о событии, на которое он не записан: например, что мест по подписке не осталось
+alter table scheduled_notifications
+  add column if not exists event_id bigint references events(id) on delete cascade;
+
+drop index if exists scheduled_notifications_user_pending_idx;
+create unique index if not exists scheduled_notifications_user_pending_idx
+  on scheduled_notifications(user_id, kind, coalesce(event_id, 0)) where status = 'pending';
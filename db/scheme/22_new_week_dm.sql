@@ -0,0 +1,7 @@
+-- 22_new_week_dm.sql
+-- Когда игрокам разослали личное сообщение о новой игре недели. Повторный запуск рассылки
+-- пропускает игры, о которых уже сообщали
+alter table events add column if not exists new_week_dm_at timestamptz;
+
+-- О существующих играх сообщение уже ушло вместе с анонсом в группе
+update events set new_week_dm_at = now() where new_week_dm_at is null;
@@ -0,0 +1,4 @@
+-- 23_quorum_sent.sql
+-- Когда в группу отправили сообщение о том, что на игру набрался кворум.
+-- Сообщение отправляется один раз на событие
+alter table events add column if not exists quorum_sent_at timestamptz;
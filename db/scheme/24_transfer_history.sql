@@ -0,0 +1,16 @@
+-- 24_transfer_history.sql
+-- История передач мест переживает отмену регистрации: у передачи своё событие,
+-- а ссылка на регистрацию при её удалении обнуляется
+alter table registration_transfers
+  add column if not exists event_id bigint references events(id) on delete cascade;
+
+update registration_transfers t set event_id = r.event_id
+from registrations r
+where r.id = t.registration_id and t.event_id is null;
+
+alter table registration_transfers
+  alter column event_id set not null,
+  alter column registration_id drop not null,
+  drop constraint if exists registration_transfers_registration_id_fkey,
+  add constraint registration_transfers_registration_id_fkey
+    foreign key (registration_id) references registrations(id) on delete set null;
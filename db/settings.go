@@ -0,0 +1,158 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Настройки уведомлений игрока. Если строки в user_settings нет, действуют значения по умолчанию
+type UserSettings struct {
+	Reminder24 bool
+	Reminder3  bool
+	Reminder1  bool
+	NewWeekDM  bool
+	QuietFrom  sql.NullInt16
+	QuietTo    sql.NullInt16
+}
+
+var DefaultUserSettings = UserSettings{Reminder24: true, Reminder1: true}
+
+// Получатель персонального уведомления
+type Recipient struct {
+	TelegramID int64
+	ChatID     int64
+	Language   string
+	QuietFrom  sql.NullInt16
+	QuietTo    sql.NullInt16
+}
+
+// Включено ли у игрока напоминание данного типа. Неизвестные типы считаются включёнными
+func (s UserSettings) Wants(kind string) bool {
+	switch kind {
+	case KindReminder24:
+		return s.Reminder24
+	case KindReminder3:
+		return s.Reminder3
+	case KindReminder1:
+		return s.Reminder1
+	case KindNewWeek:
+		return s.NewWeekDM
+	}
+	return true
+}
+
+// Тихие часы: с QuietFrom включительно до QuietTo не включительно, возможно через полночь
+func InQuietHours(from, to sql.NullInt16, t time.Time) bool {
+	if !from.Valid || !to.Valid || from.Int16 == to.Int16 {
+		return false
+	}
+	h := int16(t.Hour())
+	if from.Int16 < to.Int16 {
+		return h >= from.Int16 && h < to.Int16
+	}
+	return h >= from.Int16 || h < to.Int16
+}
+
+// Ближайший момент после t, когда тихие часы заканчиваются
+func QuietHoursEnd(to sql.NullInt16, t time.Time) time.Time {
+	end := time.Date(t.Year(), t.Month(), t.Day(), int(to.Int16), 0, 0, 0, t.Location())
+	if !end.After(t) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+func GetUserSettings(telegramID int64) UserSettings {
+	s := DefaultUserSettings
+	err := DB.QueryRow(`
+	SELECT s.reminder24, s.reminder3, s.reminder1, s.new_week_dm, s.quiet_from, s.quiet_to
+	FROM user_settings s
+	JOIN users u ON u.id = s.user_id
+	WHERE u.telegram_id = $1`, telegramID).
+		Scan(&s.Reminder24, &s.Reminder3, &s.Reminder1, &s.NewWeekDM, &s.QuietFrom, &s.QuietTo)
+	if err != nil && err != sql.ErrNoRows {
+		log.Println("GetUserSettings error:", err)
+	}
+	return s
+}
+
+func SaveUserSettings(telegramID int64, s UserSettings) error {
+	_, err := DB.Exec(`
+	INSERT INTO user_settings (user_id, reminder24, reminder3, reminder1, new_week_dm, quiet_from, quiet_to)
+	SELECT id, $2, $3, $4, $5, $6, $7 FROM users WHERE telegram_id = $1
+	ON CONFLICT (user_id) DO UPDATE SET
+		reminder24 = EXCLUDED.reminder24,
+		reminder3 = EXCLUDED.reminder3,
+		reminder1 = EXCLUDED.reminder1,
+		new_week_dm = EXCLUDED.new_week_dm,
+		quiet_from = EXCLUDED.quiet_from,
+		quiet_to = EXCLUDED.quiet_to,
+		updated_at = now()`,
+		telegramID, s.Reminder24, s.Reminder3, s.Reminder1, s.NewWeekDM, s.QuietFrom, s.QuietTo)
+	if err != nil {
+		return fmt.Errorf("SaveUserSettings error: %v", err)
+	}
+	return nil
+}
+
+// Отметить, что о событиях разослано личное сообщение о новой неделе.
+// Возвращает только те, о которых ещё не сообщали, поэтому повторный вызов вернёт пустой список
+func ClaimNewWeekEvents(events []Event) ([]Event, error) {
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = int64(e.ID)
+	}
+	rows, err := DB.Query(`
+	UPDATE events SET new_week_dm_at = now()
+	WHERE id = ANY($1) AND new_week_dm_at IS NULL
+	RETURNING id`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("ClaimNewWeekEvents error: %v", err)
+	}
+	defer rows.Close()
+
+	claimed := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ClaimNewWeekEvents scan error: %v", err)
+		}
+		claimed[id] = true
+	}
+	var fresh []Event
+	for _, e := range events {
+		if claimed[e.ID] {
+			fresh = append(fresh, e)
+		}
+	}
+	return fresh, nil
+}
+
+// Игроки, которые хотят получать личное сообщение о новых играх недели
+func GetNewWeekSubscribers() []Recipient {
+	rows, err := DB.Query(`
+	SELECT u.telegram_id, u.chat_id, coalesce(u.language, ''), s.quiet_from, s.quiet_to
+	FROM user_settings s
+	JOIN users u ON u.id = s.user_id
+	WHERE s.new_week_dm AND u.blocked_at IS NULL AND u.chat_id > 0`)
+	if err != nil {
+		log.Println("GetNewWeekSubscribers error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var recipients []Recipient
+	for rows.Next() {
+		var r Recipient
+		if err := rows.Scan(&r.TelegramID, &r.ChatID, &r.Language, &r.QuietFrom, &r.QuietTo); err != nil {
+			log.Println("GetNewWeekSubscribers scan error:", err)
+			continue
+		}
+		recipients = append(recipients, r)
+	}
+	return recipients
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"fmt"
+	"log"
+)
+
+// Подписка игрока на серию регулярных игр
+type SeriesSubscription struct {
+	Series string
+	Paused bool
+}
+
+// Подписчик, которого записали на новую игру серии
+type Subscriber struct {
+	TelegramID int64
+	ChatID     int64
+	Language   string
+}
+
+func Subscribe(telegramID int64, series string) error {
+	_, err := DB.Exec(`
+	INSERT INTO series_subscriptions (user_id, series)
+	SELECT id, $2 FROM users WHERE telegram_id = $1
+	ON CONFLICT (user_id, series) DO UPDATE SET paused = false, updated_at = now()`, telegramID, series)
+	if err != nil {
+		return fmt.Errorf("Subscribe error: %v", err)
+	}
+	return nil
+}
+
+func Unsubscribe(telegramID int64, series string) error {
+	_, err := DB.Exec(`
+	DELETE FROM series_subscriptions s USING users u
+	WHERE u.id = s.user_id AND u.telegram_id = $1 AND s.series = $2`, telegramID, series)
+	if err != nil {
+		return fmt.Errorf("Unsubscribe error: %v", err)
+	}
+	return nil
+}
+
+// Приостановить подписку или возобновить её. Приостановленная подписка сохраняет очередь:
+// после возобновления игрок записывается раньше тех, кто подписался позже
+func SetSubscriptionPaused(telegramID int64, series string, paused bool) error {
+	_, err := DB.Exec(`
+	UPDATE series_subscriptions s SET paused = $3, updated_at = now()
+	FROM users u
+	WHERE u.id = s.user_id AND u.telegram_id = $1 AND s.series = $2`, telegramID, series, paused)
+	if err != nil {
+		return fmt.Errorf("SetSubscriptionPaused error: %v", err)
+	}
+	return nil
+}
+
+// Подписки игрока по ключу серии
+func GetUserSubscriptions(telegramID int64) map[string]SeriesSubscription {
+	subs := map[string]SeriesSubscription{}
+	rows, err := DB.Query(`
+	SELECT s.series, s.paused
+	FROM series_subscriptions s
+	JOIN users u ON u.id = s.user_id
+	WHERE u.telegram_id = $1`, telegramID)
+	if err != nil {
+		log.Println("GetUserSubscriptions error:", err)
+		return subs
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s SeriesSubscription
+		if err := rows.Scan(&s.Series, &s.Paused); err != nil {
+			log.Println("GetUserSubscriptions scan error:", err)
+			continue
+		}
+		subs[s.Series] = s
+	}
+	return subs
+}
+
+// Активные подписчики серии в порядке подписки: кто подписался раньше, того записывают первым.
+// Заблокировавшие бота и удалившие свои данные пропускаются
+func GetSeriesSubscribers(series string) []Subscriber {
+	rows, err := DB.Query(`
+	SELECT u.telegram_id, u.chat_id, coalesce(u.language, '')
+	FROM series_subscriptions s
+	JOIN users u ON u.id = s.user_id
+	WHERE s.series = $1 AND NOT s.paused AND u.blocked_at IS NULL AND u.telegram_id > 0
+	ORDER BY s.created_at`, series)
+	if err != nil {
+		log.Println("GetSeriesSubscribers error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var subscribers []Subscriber
+	for rows.Next() {
+		var s Subscriber
+		if err := rows.Scan(&s.TelegramID, &s.ChatID, &s.Language); err != nil {
+			log.Println("GetSeriesSubscribers scan error:", err)
+			continue
+		}
+		subscribers = append(subscribers, s)
+	}
+	return subscribers
+}
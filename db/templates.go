@@ -0,0 +1,70 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Изменённый администратором шаблон сообщения
+type MessageTemplate struct {
+	Name      string
+	Language  string
+	Body      string
+	UpdatedBy int64
+	UpdatedAt time.Time
+}
+
+// Текст шаблона из базы. ok == false, если шаблон не менялся и нужно взять текст по умолчанию.
+// Ошибка означает, что база недоступна и неизвестно, менялся ли шаблон
+func GetMessageTemplate(name, language string) (body string, ok bool, err error) {
+	err = DB.QueryRow(`SELECT body FROM message_templates WHERE name = $1 AND language = $2`, name, language).Scan(&body)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("GetMessageTemplate error: %v", err)
+	}
+	return body, true, nil
+}
+
+func GetMessageTemplates() []MessageTemplate {
+	rows, err := DB.Query(`SELECT name, language, body, coalesce(updated_by, 0), updated_at FROM message_templates ORDER BY name, language`)
+	if err != nil {
+		log.Println("GetMessageTemplates error:", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var templates []MessageTemplate
+	for rows.Next() {
+		var t MessageTemplate
+		if err := rows.Scan(&t.Name, &t.Language, &t.Body, &t.UpdatedBy, &t.UpdatedAt); err != nil {
+			log.Println("GetMessageTemplates scan error:", err)
+			continue
+		}
+		templates = append(templates, t)
+	}
+	return templates
+}
+
+func SaveMessageTemplate(name, language, body string, updatedBy int64) error {
+	_, err := DB.Exec(`
+	INSERT INTO message_templates (name, language, body, updated_by)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (name, language) DO UPDATE
+	SET body = EXCLUDED.body, updated_by = EXCLUDED.updated_by, updated_at = now()`, name, language, body, updatedBy)
+	if err != nil {
+		return fmt.Errorf("SaveMessageTemplate error: %v", err)
+	}
+	return nil
+}
+
+func DeleteMessageTemplate(name, language string) error {
+	_, err := DB.Exec(`DELETE FROM message_templates WHERE name = $1 AND language = $2`, name, language)
+	if err != nil {
+		return fmt.Errorf("DeleteMessageTemplate error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrTransferHasGuests   = errors.New("registration has guests")
+	ErrAlreadyRegistered   = errors.New("user already registered")
+	ErrTransferUnavailable = errors.New("transfer is no longer available")
+)
+
+// Передача места на игре
+type Transfer struct {
+	ID             int64
+	RegistrationID int
+	EventID        int
+	FromTelegramID int64
+	ToTelegramID   int64
+}
+
+// Предложить место игрока fromTelegramID на событии eventID игроку toTelegramID.
+// Прежние неподтверждённые предложения по этой регистрации отменяются
+func CreateTransfer(fromTelegramID int64, eventID int, toTelegramID int64) (Transfer, error) {
+	t := Transfer{EventID: eventID, FromTelegramID: fromTelegramID, ToTelegramID: toTelegramID}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return t, fmt.Errorf("CreateTransfer begin error: %v", err)
+	}
+	defer tx.Rollback()
+
+	var fromUserID, toUserID int
+	err = tx.QueryRow(`
+	SELECT r.id, r.user_id FROM registrations r
+	JOIN users u ON u.id = r.user_id
+	JOIN events e ON e.id = r.event_id
+	WHERE u.telegram_id = $1 AND r.event_id = $2 AND e.starts_at > now()
+	FOR UPDATE OF r`, fromTelegramID, eventID).Scan(&t.RegistrationID, &fromUserID)
+	if err == sql.ErrNoRows {
+		return t, ErrTransferUnavailable
+	}
+	if err != nil {
+		return t, fmt.Errorf("CreateTransfer select error: %v", err)
+	}
+
+	// Гости привязаны к игроку, а не к месту — их нужно убрать до передачи
+	var guests int
+	if err := tx.QueryRow(`SELECT count(*) FROM registrations WHERE host_registration_id = $1`, t.RegistrationID).Scan(&guests); err != nil {
+		return t, fmt.Errorf("CreateTransfer guests error: %v", err)
+	}
+	if guests > 0 {
+		return t, ErrTransferHasGuests
+	}
+
+	var registered bool
+	err = tx.QueryRow(`
+	SELECT u.id, EXISTS(SELECT 1 FROM registrations r WHERE r.user_id = u.id AND r.event_id = $2)
+	FROM users u WHERE u.telegram_id = $1`, toTelegramID, eventID).Scan(&toUserID, &registered)
+	if err != nil {
+		return t, fmt.Errorf("пользователь не найден")
+	}
+	if registered {
+		return t, ErrAlreadyRegistered
+	}
+
+	_, err = tx.Exec(`
+	UPDATE registration_transfers SET status = 'cancelled', resolved_at = now()
+	WHERE registration_id = $1 AND status = 'pending'`, t.RegistrationID)
+	if err != nil {
+		return t, fmt.Errorf("CreateTransfer cancel error: %v", err)
+	}
+
+	err = tx.QueryRow(`
+	INSERT INTO registration_transfers (registration_id, event_id, from_user_id, to_user_id) VALUES ($1, $2, $3, $4)
+	RETURNING id`, t.RegistrationID, eventID, fromUserID, toUserID).Scan(&t.ID)
+	if err != nil {
+		return t, fmt.Errorf("CreateTransfer insert error: %v", err)
+	}
+	return t, tx.Commit()
+}
+
+// Принять место. Владелец регистрации меняется одной транзакцией: число записавшихся не меняется,
+// поэтому место не может достаться кому-то ещё между отменой и новой записью.
+// Абонемент подбирается заново уже для нового владельца, напоминания планируются ему
+func AcceptTransfer(transferID int64, toTelegramID int64) (Transfer, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return Transfer{}, fmt.Errorf("AcceptTransfer begin error: %v", err)
+	}
+	defer tx.Rollback()
+
+	t, toUserID, err := claimTransfer(tx, transferID, toTelegramID)
+	if err != nil {
+		return t, err
+	}
+
+	var starts bool
+	err = tx.QueryRow(`SELECT starts_at > now() FROM events WHERE id = $1`, t.EventID).Scan(&starts)
+	if err != nil || !starts {
+		return t, ErrTransferUnavailable
+	}
+
+	var registered bool
+	var guests int
+	err = tx.QueryRow(`
+	SELECT EXISTS(SELECT 1 FROM registrations WHERE user_id = $1 AND event_id = $2),
+		(SELECT count(*) FROM registrations WHERE host_registration_id = $3)`, toUserID, t.EventID, t.RegistrationID).Scan(&registered, &guests)
+	if err != nil {
+		return t, fmt.Errorf("AcceptTransfer select error: %v", err)
+	}
+	if registered {
+		return t, ErrAlreadyRegistered
+	}
+	if guests > 0 {
+		return t, ErrTransferHasGuests
+	}
+
+	_, err = tx.Exec(`
+	UPDATE registrations SET user_id = $1, registered_by = NULL, membership_id = `+availableMembershipQuery+`, updated_at = now()
+	WHERE id = $3`, toUserID, t.EventID, t.RegistrationID)
+	if err != nil {
+		return t, fmt.Errorf("AcceptTransfer update error: %v", err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM scheduled_notifications WHERE registration_id = $1 AND status = 'pending'`, t.RegistrationID)
+	if err != nil {
+		return t, fmt.Errorf("AcceptTransfer notifications error: %v", err)
+	}
+	if err := scheduleReminders(tx, int64(t.RegistrationID)); err != nil {
+		return t, err
+	}
+
+	if err := resolveTransfer(tx, transferID, "accepted"); err != nil {
+		return t, err
+	}
+	return t, tx.Commit()
+}
+
+// Отказаться от предложенного места
+func DeclineTransfer(transferID int64, toTelegramID int64) (Transfer, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return Transfer{}, fmt.Errorf("DeclineTransfer begin error: %v", err)
+	}
+	defer tx.Rollback()
+
+	t, _, err := claimTransfer(tx, transferID, toTelegramID)
+	if err != nil {
+		return t, err
+	}
+	if err := resolveTransfer(tx, transferID, "declined"); err != nil {
+		return t, err
+	}
+	return t, tx.Commit()
+}
+
+// Заблокировать предложение, адресованное toTelegramID. Предложение недоступно,
+// если оно уже решено или место больше не принадлежит тому, кто его предлагал
+func claimTransfer(tx *sql.Tx, transferID int64, toTelegramID int64) (Transfer, int, error) {
+	var t Transfer
+	var toUserID int
+	err := tx.QueryRow(`
+	SELECT t.id, t.registration_id, r.event_id, fu.telegram_id, tu.telegram_id, tu.id
+	FROM registration_transfers t
+	JOIN registrations r ON r.id = t.registration_id AND r.user_id = t.from_user_id
+	JOIN users fu ON fu.id = t.from_user_id
+	JOIN users tu ON tu.id = t.to_user_id
+	WHERE t.id = $1 AND t.status = 'pending' AND tu.telegram_id = $2
+	FOR UPDATE OF t, r`, transferID, toTelegramID).Scan(&t.ID, &t.RegistrationID, &t.EventID, &t.FromTelegramID, &t.ToTelegramID, &toUserID)
+	if err == sql.ErrNoRows {
+		return t, 0, ErrTransferUnavailable
+	}
+	if err != nil {
+		return t, 0, fmt.Errorf("claimTransfer error: %v", err)
+	}
+	return t, toUserID, nil
+}
+
+func resolveTransfer(tx *sql.Tx, transferID int64, status string) error {
+	_, err := tx.Exec(`UPDATE registration_transfers SET status = $2, resolved_at = now() WHERE id = $1`, transferID, status)
+	if err != nil {
+		return fmt.Errorf("resolveTransfer error: %v", err)
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"laverdad-bot/db"
+	"laverdad-bot/locales"
 	"log"
 	"strconv"
+	"sync"
 	"time"
 
 	"google.golang.org/api/option"
@@ -14,35 +16,70 @@ import (
 
 var service *sheets.Service
 var spreadSheetID = "1fLXbsWPb7kdGGGllHLH1YI7W96fBH0Z1oZNDK0C6nvk"
-var err error
+
+// Незавершённые фоновые записи в таблицу, которые нужно дождаться при остановке бота
+var pending sync.WaitGroup
 
 func InitSheetService() {
 	ctx := context.Background()
 
 	// --- Инициализация Google Sheets API ---
+	var err error
 	service, err = sheets.NewService(ctx, option.WithCredentialsFile("secrets/service_account.json"))
 	if err != nil {
 		log.Fatalf("Unable to create Sheets service: %v", err)
 	}
 }
 
+// Проверка доступа к таблице
+func Ping(ctx context.Context) error {
+	_, err := service.Spreadsheets.Get(spreadSheetID).Fields("spreadsheetId").Context(ctx).Do()
+	return err
+}
+
+// Выполнить запись в таблицу в фоне так, чтобы её можно было дождаться через Wait
+func Async(fn func()) {
+	pending.Add(1)
+	go func() {
+		defer pending.Done()
+		fn()
+	}()
+}
+
+// Дождаться завершения фоновых записей, но не дольше, чем позволяет ctx
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func AddNewSheet(sheetName string) {
 	ctx := context.Background()
 
 	// Создаём новый лист в таблице
 	addSheetReq := &sheets.AddSheetRequest{Properties: &sheets.SheetProperties{Title: sheetName}}
-	_, err = service.Spreadsheets.BatchUpdate(spreadSheetID, &sheets.BatchUpdateSpreadsheetRequest{
+	_, err := service.Spreadsheets.BatchUpdate(spreadSheetID, &sheets.BatchUpdateSpreadsheetRequest{
 		Requests: []*sheets.Request{{AddSheet: addSheetReq}},
 	}).Context(ctx).Do()
 	if err != nil {
 		log.Printf("Unable to add new sheet with name: %v; error:%v", sheetName, err)
+		return
 	}
 	log.Printf("New sheet was created: %s\n", sheetName)
 
 	// 2. Добавляем заголовки
-	rangeName := fmt.Sprintf("'%s'!A1:H1", sheetName)
+	rangeName := fmt.Sprintf("'%s'!A1:I1", sheetName)
 	_, err = service.Spreadsheets.Values.Update(spreadSheetID, rangeName, &sheets.ValueRange{
-		Values: [][]any{{"ID", "TelegramLink", "Username", "Имя", "Игровой Ник", "Статус", "CreatedAt", "UpdatedAt"}},
+		Values: [][]any{{"ID", "TelegramLink", "Username", "Имя", "Игровой Ник", "Статус", "CreatedAt", "UpdatedAt", "Оплата"}},
 	}).ValueInputOption("RAW").Context(ctx).Do()
 	if err != nil {
 		log.Printf("Unable to set headers: %v", err)
@@ -52,45 +89,210 @@ func AddNewSheet(sheetName string) {
 func AddRegistrationToSheet(sheetName string, line db.RegistrationLine) error {
 	ctx := context.Background()
 
-	username := fmt.Sprintf("@%s", line.UserName.String)
-	rangeName := fmt.Sprintf("'%s'!A2:H2", sheetName)
-	_, err = service.Spreadsheets.Values.Append(spreadSheetID, rangeName, &sheets.ValueRange{
-		Values: [][]any{{line.ID, line.TelegramLink, username, line.Name, line.NickName, line.Status, line.CreatedAt.Format("02.01.2006 15:04"), line.UpdatedAt.Format("02.01.2006 15:04")}},
+	username := sheetUsername(line.UserName.String)
+	nickname := line.NickName
+	if line.Guest {
+		// У гостя нет Telegram: в колонке ника отмечаем, чей он гость
+		nickname = GuestMark(line.GuestOf)
+	}
+	payment := "донат"
+	if line.MembershipID.Valid {
+		payment = "абонемент"
+	}
+	rangeName := fmt.Sprintf("'%s'!A2:I2", sheetName)
+	_, err := service.Spreadsheets.Values.Append(spreadSheetID, rangeName, &sheets.ValueRange{
+		Values: [][]any{{line.ID, line.TelegramLink, username, line.Name, nickname, line.Status, sheetTime(line.CreatedAt), sheetTime(line.UpdatedAt), payment}},
 	}).ValueInputOption("RAW").InsertDataOption("INSERT_ROWS").Context(ctx).Do()
 
 	return err
 }
 
-func UpdateRegistrationStateToSheet(regID int, sheetName string, updatedAt time.Time) {
-	log.Printf("Start updating google spread sheets for '%d', and event: %s\n", regID, sheetName)
+// Отметка гостя в колонке ника: чей он гость
+func GuestMark(hostNickname string) string {
+	if hostNickname == "" {
+		return "гость"
+	}
+	return fmt.Sprintf("гость @%s", hostNickname)
+}
+
+// Имя листа с регистрациями на событие
+func SheetName(title string, startsAt time.Time) string {
+	return fmt.Sprintf("%s - %s", title, locales.ClubTime(startsAt).Format("02.01"))
+}
+
+// @username для таблицы; без username ячейка остаётся пустой, а не «@»
+func sheetUsername(userName string) string {
+	if userName == "" {
+		return ""
+	}
+	return fmt.Sprintf("@%s", userName)
+}
+
+// Время в таблице показывается по часам клуба
+func sheetTime(t time.Time) string {
+	return locales.ClubTime(t).Format("02.01.2006 15:04")
+}
+
+// Номер строки листа, в которой записана регистрация regID
+func findRegistrationRow(regID int, sheetName string) (int, error) {
 	resp, err := service.Spreadsheets.Values.Get(spreadSheetID, fmt.Sprintf("'%s'!A2:A", sheetName)).Do()
 	if err != nil {
-		log.Printf("Unable to read data: %v", err)
+		return -1, fmt.Errorf("unable to read data: %v", err)
 	}
 
-	rowIndex := -1
-
 	for i, row := range resp.Values {
-		log.Printf("i:%d; %v\n", i, row)
-
-		if fmt.Sprintf("%v", row[0]) == strconv.Itoa(regID) {
-			rowIndex = i + 2
-			break
+		if len(row) > 0 && fmt.Sprintf("%v", row[0]) == strconv.Itoa(regID) {
+			return i + 2, nil
 		}
 	}
 
-	if rowIndex == -1 {
-		log.Printf("Sheets error: Registration with id=%d not found", regID)
+	return -1, fmt.Errorf("registration with id=%d not found", regID)
+}
+
+func UpdateRegistrationStateToSheet(regID int, sheetName string, updatedAt time.Time) {
+	log.Printf("Start updating google spread sheets for '%d', and event: %s\n", regID, sheetName)
+	rowIndex, err := findRegistrationRow(regID, sheetName)
+	if err != nil {
+		log.Printf("Sheets error: %v", err)
 		return
 	}
 
 	rangeName := fmt.Sprintf("'%s'!F%d:H%d", sheetName, rowIndex, rowIndex)
-	vr := sheets.ValueRange{Values: [][]any{{"canceled", nil, updatedAt.Format("02.01.2006 15:04")}}}
+	vr := sheets.ValueRange{Values: [][]any{{"canceled", nil, sheetTime(updatedAt)}}}
 	_, err = service.Spreadsheets.Values.Update(spreadSheetID, rangeName, &vr).ValueInputOption("RAW").Do()
 
 	if err != nil {
 		log.Printf("Unable to update status: %v", err)
+		return
 	}
 
 	log.Println("Статус обновлён на canceled")
 }
+
+// Обновить данные игрока (username, имя, ник) в строке регистрации
+func UpdateRegistrationUserToSheet(regID int, sheetName string, userName, name, nickname string, updatedAt time.Time) {
+	rowIndex, err := findRegistrationRow(regID, sheetName)
+	if err != nil {
+		log.Printf("Sheets error: %v", err)
+		return
+	}
+
+	rangeName := fmt.Sprintf("'%s'!C%d:E%d", sheetName, rowIndex, rowIndex)
+	vr := sheets.ValueRange{Values: [][]any{{sheetUsername(userName), name, nickname}}}
+	_, err = service.Spreadsheets.Values.Update(spreadSheetID, rangeName, &vr).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Unable to update user data: %v", err)
+		return
+	}
+
+	rangeName = fmt.Sprintf("'%s'!H%d", sheetName, rowIndex)
+	vr = sheets.ValueRange{Values: [][]any{{sheetTime(updatedAt)}}}
+	_, err = service.Spreadsheets.Values.Update(spreadSheetID, rangeName, &vr).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Unable to update updatedAt: %v", err)
+	}
+}
+
+// Записать в строку регистрации нового владельца места после передачи
+func TransferRegistrationInSheet(sheetName string, line db.RegistrationLine) {
+	rowIndex, err := findRegistrationRow(line.ID, sheetName)
+	if err != nil {
+		log.Printf("Sheets error: %v", err)
+		return
+	}
+
+	payment := "донат"
+	if line.MembershipID.Valid {
+		payment = "абонемент"
+	}
+	// Статус и время создания (F, G) не трогаем: nil оставляет ячейку как есть
+	rangeName := fmt.Sprintf("'%s'!B%d:I%d", sheetName, rowIndex, rowIndex)
+	vr := sheets.ValueRange{Values: [][]any{{line.TelegramLink, sheetUsername(line.UserName.String), line.Name, line.NickName, nil, nil, sheetTime(line.UpdatedAt), payment}}}
+	_, err = service.Spreadsheets.Values.Update(spreadSheetID, rangeName, &vr).ValueInputOption("RAW").Do()
+	if err != nil {
+		log.Printf("Unable to transfer registration: %v", err)
+	}
+}
+
+// Строки таблицы, которые нужно обезличить по /forget_me
+type ForgottenRows struct {
+	TelegramLink string       // ссылка на игрока в колонке B: его регистрации, в том числе отменённые
+	GuestMark    string       // «гость @ник» в колонке E: гости игрока, отменённые тоже
+	GuestIDs     map[int]bool // id регистраций гостей игрока — на случай, если он менял ник
+}
+
+// Сколько листов читать одним запросом
+const forgetBatchSheets = 50
+
+// Стереть персональные данные игрока на всех листах. Отменённых регистраций в базе уже нет,
+// поэтому строки ищутся по содержимому таблицы, а не по id из базы
+func ForgetUserInSheets(rows ForgottenRows, updatedAt time.Time) error {
+	ctx := context.Background()
+	spreadsheet, err := service.Spreadsheets.Get(spreadSheetID).Fields("sheets.properties.title").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("ForgetUserInSheets sheets error: %v", err)
+	}
+	var titles []string
+	for _, sh := range spreadsheet.Sheets {
+		titles = append(titles, sh.Properties.Title)
+	}
+
+	var updates []*sheets.ValueRange
+	for start := 0; start < len(titles); start += forgetBatchSheets {
+		batch := titles[start:min(start+forgetBatchSheets, len(titles))]
+		ranges := make([]string, len(batch))
+		for i, title := range batch {
+			ranges[i] = fmt.Sprintf("'%s'!A2:E", title)
+		}
+		resp, err := service.Spreadsheets.Values.BatchGet(spreadSheetID).Ranges(ranges...).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("ForgetUserInSheets read error: %v", err)
+		}
+		for i, vr := range resp.ValueRanges {
+			for j, row := range vr.Values {
+				values := forgottenRowValues(rows, row)
+				if values == nil {
+					continue
+				}
+				n := j + 2
+				updates = append(updates,
+					&sheets.ValueRange{Range: fmt.Sprintf("'%s'!B%d:E%d", batch[i], n, n), Values: [][]any{values}},
+					&sheets.ValueRange{Range: fmt.Sprintf("'%s'!H%d", batch[i], n), Values: [][]any{{sheetTime(updatedAt)}}},
+				)
+			}
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	_, err = service.Spreadsheets.Values.BatchUpdate(spreadSheetID, &sheets.BatchUpdateValuesRequest{
+		ValueInputOption: "RAW",
+		Data:             updates,
+	}).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("ForgetUserInSheets update error: %v", err)
+	}
+	log.Printf("ForgetUserInSheets: %d rows anonymized\n", len(updates)/2)
+	return nil
+}
+
+// Новые значения колонок B:E для строки, если она относится к забываемому игроку, иначе nil
+func forgottenRowValues(rows ForgottenRows, row []any) []any {
+	cell := func(i int) string {
+		if i < len(row) {
+			return fmt.Sprintf("%v", row[i])
+		}
+		return ""
+	}
+	switch {
+	case rows.TelegramLink != "" && cell(1) == rows.TelegramLink:
+		return []any{"", "", "Удалённый игрок", ""}
+	case rows.GuestMark != "" && cell(4) == rows.GuestMark:
+		return []any{"", "", "Гость", "гость"}
+	}
+	if id, err := strconv.Atoi(cell(0)); err == nil && rows.GuestIDs[id] {
+		return []any{"", "", "Гость", "гость"}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package googleapi
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestForgottenRowValues(t *testing.T) {
+	rows := ForgottenRows{TelegramLink: "tg://user?id=5001", GuestMark: GuestMark("ana_m"), GuestIDs: map[int]bool{31: true}}
+	player := []any{"", "", "Удалённый игрок", ""}
+	guest := []any{"", "", "Гость", "гость"}
+
+	for _, tc := range []struct {
+		name string
+		row  []any
+		want []any
+	}{
+		{"own registration", []any{"12", "tg://user?id=5001", "@ana", "Ана", "ana_m", "active"}, player},
+		{"cancelled registration", []any{"9", "tg://user?id=5001", "@ana", "Ана", "ana_m", "canceled"}, player},
+		{"guest by mark", []any{"40", "", "", "Пабло", "гость @ana_m", "canceled"}, guest},
+		{"guest by id after a nickname change", []any{"31", "", "", "Хуан", "гость @old_nick"}, guest},
+		{"other player", []any{"13", "tg://user?id=50011", "@bob", "Боб", "bob"}, nil},
+		{"other host's guest", []any{"41", "", "", "Луис", "гость @ana_m2"}, nil},
+		{"short row", []any{"14"}, nil},
+		{"empty row", nil, nil},
+	} {
+		if got := forgottenRowValues(rows, tc.row); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package locales
+
+import (
+	"fmt"
+	"time"
+)
+
+func FormatDateEN(t time.Time) string {
+	return fmt.Sprintf("%s, %d %s", t.Weekday(), t.Day(), t.Month())
+}
+
+func FormatDateShortEN(t time.Time) string {
+	return fmt.Sprintf("%d %s (%s)", t.Day(), t.Month(), t.Weekday().String()[:3])
+}
@@ -0,0 +1,33 @@
+package locales
+
+import (
+	"fmt"
+	"time"
+)
+
+var esMonths = []string{
+	"enero", "febrero", "marzo", "abril", "mayo", "junio",
+	"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+}
+var esWeekdays = []string{
+	"domingo", "lunes", "martes", "miércoles",
+	"jueves", "viernes", "sábado",
+}
+
+var esWeekdaysShort = []string{
+	"dom", "lun", "mar", "mié", "jue", "vie", "sáb",
+}
+
+func FormatDateES(t time.Time) string {
+	day := t.Day()
+	month := esMonths[int(t.Month())-1]
+	weekday := esWeekdays[int(t.Weekday())]
+	return fmt.Sprintf("%s, %d de %s", weekday, day, month)
+}
+
+func FormatDateShortES(t time.Time) string {
+	day := t.Day()
+	month := esMonths[int(t.Month())-1]
+	weekday := esWeekdaysShort[int(t.Weekday())]
+	return fmt.Sprintf("%d de %s (%s)", day, month, weekday)
+}
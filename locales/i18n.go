@@ -0,0 +1,85 @@
+package locales
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+type Lang string
+
+const (
+	RU Lang = "ru"
+	ES Lang = "es"
+	EN Lang = "en"
+)
+
+// Язык клуба: на нём пишутся сообщения в группу и подставляются недостающие переводы
+const DefaultLang = RU
+
+var Langs = []Lang{RU, ES, EN}
+
+// Название языка на нём самом — для кнопок выбора
+var LangNames = map[Lang]string{
+	RU: "🇷🇺 Русский",
+	ES: "🇪🇸 Español",
+	EN: "🇬🇧 English",
+}
+
+// Язык по language_code из Telegram или по значению из базы.
+// Русскоязычные соседние языки считаем русским, каталонский и галисийский — испанским,
+// всё остальное — английским. Пустой код — язык клуба
+func ParseLang(code string) Lang {
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	switch code {
+	case "":
+		return DefaultLang
+	case "ru", "uk", "be", "kk":
+		return RU
+	case "es", "ca", "gl", "eu":
+		return ES
+	}
+	return EN
+}
+
+// Перевод сообщения key. Если перевода нет, используется язык клуба
+func T(lang Lang, key string, args ...any) string {
+	text, ok := messages[key][lang]
+	if !ok {
+		text, ok = messages[key][DefaultLang]
+	}
+	if !ok {
+		log.Printf("locales: no message %q\n", key)
+		return key
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// «пятница, 12 июня» на языке lang
+func FormatDate(lang Lang, t time.Time) string {
+	switch lang {
+	case ES:
+		return FormatDateES(t)
+	case EN:
+		return FormatDateEN(t)
+	}
+	return FormatDateRU(t)
+}
+
+// «12 июня (пт)» на языке lang
+func FormatDateShort(lang Lang, t time.Time) string {
+	switch lang {
+	case ES:
+		return FormatDateShortES(t)
+	case EN:
+		return FormatDateShortEN(t)
+	}
+	return FormatDateShortRU(t)
+}
@@ -0,0 +1,669 @@
+package locales
+
+// Каталог сообщений для игроков. Тексты — HTML-разметка Telegram (см. пакет render):
+// в сообщения с разметкой аргументы подставляются через render.T, который их экранирует.
+// Админские команды и сообщения в группу клуба остаются на русском
+var messages = map[string]map[Lang]string{
+	// Регистрация в боте
+	"start.welcome": {
+		RU: "Привет! Я бот клуба спортивной мафии <b>La Verdad</b>. \nС помощью меня можно записаться на игры и не только 😉",
+		ES: "¡Hola! Soy el bot del club de mafia deportiva <b>La Verdad</b>. \nConmigo puedes apuntarte a las partidas y mucho más 😉",
+		EN: "Hi! I'm the bot of the <b>La Verdad</b> sports mafia club. \nI can sign you up for games and more 😉",
+	},
+	"start.ask_name": {
+		RU: "Пожалуйста пройди небольшую регистрацию\n\nВведи своё <b>имя</b>:",
+		ES: "Por favor, completa un breve registro\n\nEscribe tu <b>nombre</b>:",
+		EN: "Please complete a short registration\n\nEnter your <b>name</b>:",
+	},
+	"reg.retry_name": {
+		RU: "Не получилось: %v.\nВведи своё <b>имя</b> ещё раз:",
+		ES: "No ha funcionado: %v.\nEscribe tu <b>nombre</b> otra vez:",
+		EN: "That didn't work: %v.\nEnter your <b>name</b> again:",
+	},
+	"reg.ask_nickname": {
+		RU: "Отлично! Теперь введи свой игровой <b>ник</b>:",
+		ES: "¡Genial! Ahora escribe tu <b>apodo</b> de juego:",
+		EN: "Great! Now enter your game <b>nickname</b>:",
+	},
+	"reg.retry_nickname": {
+		RU: "Не получилось: %v.\nВведи свой игровой <b>ник</b> ещё раз:",
+		ES: "No ha funcionado: %v.\nEscribe tu <b>apodo</b> de juego otra vez:",
+		EN: "That didn't work: %v.\nEnter your game <b>nickname</b> again:",
+	},
+	"reg.nickname_save_failed": {
+		RU: "Не получилось сохранить ник, возможно он уже занят.\nВведи свой игровой <b>ник</b> ещё раз:",
+		ES: "No se pudo guardar el apodo, puede que ya esté ocupado.\nEscribe tu <b>apodo</b> de juego otra vez:",
+		EN: "Couldn't save the nickname, it may already be taken.\nEnter your game <b>nickname</b> again:",
+	},
+	"reg.done": {
+		RU: "Готово! Теперь можешь использовать команды:\n/events — Список событий\n/my — Мои регистрации и гости\n/profile — Мой профиль\n/settings — Настройки уведомлений\n/subscriptions — Постоянная запись\n/language — Язык\n/invite — Пригласить друга",
+		ES: "¡Listo! Ya puedes usar los comandos:\n/events — Lista de partidas\n/my — Mis inscripciones e invitados\n/profile — Mi perfil\n/settings — Notificaciones\n/subscriptions — Inscripción permanente\n/language — Idioma\n/invite — Invitar a un amigo",
+		EN: "Done! Now you can use the commands:\n/events — Game list\n/my — My registrations and guests\n/profile — My profile\n/settings — Notification settings\n/subscriptions — Standing sign-up\n/language — Language\n/invite — Invite a friend",
+	},
+	"help.unknown": {
+		RU: "Неизвестная команда. Доступные команды:\n/events — список событий\n/my — мои регистрации и гости\n/profile — мой профиль\n/settings — настройки уведомлений\n/subscriptions — постоянная запись\n/language — язык\n/invite — пригласить друга\n/mydata — мои данные\n/forget_me — удалить мои данные",
+		ES: "Comando desconocido. Comandos disponibles:\n/events — lista de partidas\n/my — mis inscripciones e invitados\n/profile — mi perfil\n/settings — notificaciones\n/subscriptions — inscripción permanente\n/language — idioma\n/invite — invitar a un amigo\n/mydata — mis datos\n/forget_me — borrar mis datos",
+		EN: "Unknown command. Available commands:\n/events — game list\n/my — my registrations and guests\n/profile — my profile\n/settings — notification settings\n/subscriptions — standing sign-up\n/language — language\n/invite — invite a friend\n/mydata — my data\n/forget_me — delete my data",
+	},
+
+	// Язык
+	"language.choose": {
+		RU: "Выбери язык:",
+		ES: "Elige el idioma:",
+		EN: "Choose your language:",
+	},
+	"language.saved": {
+		RU: "✅ Теперь я говорю по-русски.",
+		ES: "✅ Ahora hablo español.",
+		EN: "✅ I'll speak English now.",
+	},
+
+	// События и регистрации
+	"events.none": {
+		RU: "Пока нет доступных событий.",
+		ES: "Todavía no hay partidas disponibles.",
+		EN: "No games available yet.",
+	},
+	"events.title": {
+		RU: "Доступные мероприятия:\n\n",
+		ES: "Partidas disponibles:\n\n",
+		EN: "Available games:\n\n",
+	},
+	"my.none": {
+		RU: "Ты пока никуда не записан.",
+		ES: "Todavía no estás apuntado a ninguna partida.",
+		EN: "You haven't signed up for any games yet.",
+	},
+	"my.item": {
+		RU: "<b>%s</b>\nСтарт: %s",
+		ES: "<b>%s</b>\nInicio: %s",
+		EN: "<b>%s</b>\nStarts: %s",
+	},
+	"my.cancel": {
+		RU: "Отменить",
+		ES: "Cancelar",
+		EN: "Cancel",
+	},
+	"my.transfer": {
+		RU: "🔁 Передать",
+		ES: "🔁 Ceder",
+		EN: "🔁 Transfer",
+	},
+	"transfer.ask_target": {
+		RU: "Кому передать место? Напиши <b>ник</b> или @username игрока — он должен быть зарегистрирован в боте. /cancel — отменить:",
+		ES: "¿A quién le cedes tu plaza? Escribe el <b>apodo</b> o @usuario del jugador; tiene que estar registrado en el bot. /cancel para cancelar:",
+		EN: "Who should get your spot? Enter the player's <b>nickname</b> or @username; they must be registered in the bot. Or /cancel to cancel:",
+	},
+	"transfer.target_not_found": {
+		RU: "Игрок «%s» не найден. Проверь ник и напиши ещё раз:",
+		ES: "No se encontró al jugador «%s». Revisa el apodo y escríbelo otra vez:",
+		EN: "Player \"%s\" not found. Check the nickname and try again:",
+	},
+	"transfer.self": {
+		RU: "Это ты сам 🙂 Напиши ник другого игрока:",
+		ES: "Ese eres tú 🙂 Escribe el apodo de otro jugador:",
+		EN: "That's you 🙂 Enter another player's nickname:",
+	},
+	"transfer.offer": {
+		RU: "🔁 Игрок <b>%s</b> предлагает тебе своё место на игре <b>%s</b> (%s). Забираешь?",
+		ES: "🔁 El jugador <b>%s</b> te ofrece su plaza en la partida <b>%s</b> (%s). ¿La aceptas?",
+		EN: "🔁 Player <b>%s</b> is offering you their spot at <b>%s</b> (%s). Will you take it?",
+	},
+	"transfer.accept": {
+		RU: "✅ Забираю",
+		ES: "✅ La acepto",
+		EN: "✅ Take it",
+	},
+	"transfer.decline": {
+		RU: "❌ Не смогу",
+		ES: "❌ No puedo",
+		EN: "❌ Can't make it",
+	},
+	"transfer.sent": {
+		RU: "Предложение отправлено игроку <b>%s</b>. Место останется за тобой, пока он не подтвердит.",
+		ES: "Oferta enviada a <b>%s</b>. La plaza sigue siendo tuya hasta que la acepte.",
+		EN: "Offer sent to <b>%s</b>. The spot stays yours until they accept.",
+	},
+	"transfer.offer_failed": {
+		RU: "Не удалось отправить предложение игроку <b>%s</b>: возможно, он заблокировал бота.",
+		ES: "No se pudo enviar la oferta a <b>%s</b>: quizá ha bloqueado el bot.",
+		EN: "Couldn't send the offer to <b>%s</b>: they may have blocked the bot.",
+	},
+	"transfer.accepted": {
+		RU: "✅ Место твоё! Ты записан на игру <b>%s</b> (%s).",
+		ES: "✅ ¡La plaza es tuya! Estás apuntado a <b>%s</b> (%s).",
+		EN: "✅ The spot is yours! You're signed up for <b>%s</b> (%s).",
+	},
+	"transfer.accepted_owner": {
+		RU: "🔁 Игрок <b>%s</b> забрал твоё место на игре <b>%s</b>. Твоя запись отменена.",
+		ES: "🔁 <b>%s</b> ha aceptado tu plaza en <b>%s</b>. Tu inscripción se ha cancelado.",
+		EN: "🔁 <b>%s</b> took your spot at <b>%s</b>. Your registration has been cancelled.",
+	},
+	"transfer.declined": {
+		RU: "Ты отказался от места.",
+		ES: "Has rechazado la plaza.",
+		EN: "You declined the spot.",
+	},
+	"transfer.declined_owner": {
+		RU: "Игрок <b>%s</b> не сможет прийти на игру <b>%s</b>. Место по-прежнему твоё.",
+		ES: "<b>%s</b> no podrá ir a <b>%s</b>. La plaza sigue siendo tuya.",
+		EN: "<b>%s</b> can't make it to <b>%s</b>. The spot is still yours.",
+	},
+	"transfer.has_guests": {
+		RU: "Сначала убери своих гостей в /my — они записаны вместе с тобой.",
+		ES: "Primero quita a tus invitados en /my: están apuntados contigo.",
+		EN: "Remove your guests in /my first: they're signed up with you.",
+	},
+	"transfer.already_registered": {
+		RU: "Этот игрок уже записан на игру.",
+		ES: "Ese jugador ya está apuntado a la partida.",
+		EN: "That player is already signed up for the game.",
+	},
+	"transfer.unavailable": {
+		RU: "Предложение больше не действует: место уже передано или отменено, либо игра началась.",
+		ES: "La oferta ya no es válida: la plaza se ha cedido o cancelado, o la partida ya ha empezado.",
+		EN: "This offer is no longer valid: the spot was transferred or cancelled, or the game has started.",
+	},
+	"transfer.failed": {
+		RU: "Не удалось передать место. Попробуй ещё раз позже.",
+		ES: "No se pudo ceder la plaza. Inténtalo más tarde.",
+		EN: "Couldn't transfer the spot. Please try again later.",
+	},
+	"my.add_guest": {
+		RU: "➕ Гость",
+		ES: "➕ Invitado",
+		EN: "➕ Guest",
+	},
+	"my.remove_guest": {
+		RU: "❌ %s",
+		ES: "❌ %s",
+		EN: "❌ %s",
+	},
+	"my.guests": {
+		RU: "\nГости: %s",
+		ES: "\nInvitados: %s",
+		EN: "\nGuests: %s",
+	},
+	"guest.ask_name": {
+		RU: "Как зовут гостя? Напиши его <b>имя</b> — так его увидит ведущий. /cancel — отменить:",
+		ES: "¿Cómo se llama tu invitado? Escribe su <b>nombre</b>, así lo verá el anfitrión. /cancel para cancelar:",
+		EN: "What's your guest's name? Enter their <b>name</b> as the host will see it, or /cancel to cancel:",
+	},
+	"guest.retry_name": {
+		RU: "Не получилось: %v.\nВведи <b>имя</b> гостя ещё раз:",
+		ES: "No ha funcionado: %v.\nEscribe el <b>nombre</b> del invitado otra vez:",
+		EN: "That didn't work: %v.\nEnter the guest's <b>name</b> again:",
+	},
+	"guest.added": {
+		RU: "✅ Гость <b>%s</b> записан на игру.",
+		ES: "✅ Tu invitado <b>%s</b> está apuntado a la partida.",
+		EN: "✅ Your guest <b>%s</b> is signed up for the game.",
+	},
+	"guest.limit": {
+		RU: "На одну игру можно привести не больше %d гостей.",
+		ES: "Puedes traer como máximo %d invitados por partida.",
+		EN: "You can bring at most %d guests per game.",
+	},
+	"guest.failed": {
+		RU: "Не удалось записать гостя. Проверь, что ты сам записан на эту игру.",
+		ES: "No se pudo apuntar al invitado. Comprueba que tú estás apuntado a esta partida.",
+		EN: "Couldn't sign up your guest. Make sure you're signed up for this game yourself.",
+	},
+	"guest.removed": {
+		RU: "❌ Запись гостя %s отменена.",
+		ES: "❌ Se ha cancelado la inscripción de %s.",
+		EN: "❌ %s's registration was cancelled.",
+	},
+	"guest.remove_failed": {
+		RU: "Не удалось отменить запись гостя.",
+		ES: "No se pudo cancelar la inscripción del invitado.",
+		EN: "Couldn't cancel your guest's registration.",
+	},
+	"callback.expired_alert": {
+		RU: "Эта кнопка устарела. Пожалуйста, открой список заново.",
+		ES: "Este botón ha caducado. Por favor, abre la lista de nuevo.",
+		EN: "This button has expired. Please open the list again.",
+	},
+	"callback.expired": {
+		RU: "Эта кнопка устарела.",
+		ES: "Este botón ha caducado.",
+		EN: "This button has expired.",
+	},
+	"callback.forbidden": {
+		RU: "Нет доступа",
+		ES: "Sin acceso",
+		EN: "Access denied",
+	},
+	"event.load_failed": {
+		RU: "Ошибка: Не удалось загрузить событие.",
+		ES: "Error: no se pudo cargar la partida.",
+		EN: "Error: couldn't load the game.",
+	},
+	"start.event_unavailable": {
+		RU: "Это событие уже прошло или удалено. Посмотри другие игры: /events",
+		ES: "Esta partida ya ha pasado o se ha eliminado. Mira otras partidas: /events",
+		EN: "This game has already passed or was removed. See other games: /events",
+	},
+	"invite.text": {
+		RU: "Отправь другу эту ссылку, чтобы он записался на игры:\n%s\n\nПо твоей ссылке уже пришло игроков: %d",
+		ES: "Envía este enlace a un amigo para que se apunte a las partidas:\n%s\n\nJugadores que han llegado con tu enlace: %d",
+		EN: "Send this link to a friend so they can sign up for games:\n%s\n\nPlayers who joined with your link: %d",
+	},
+	"event.already_registered": {
+		RU: "\n\n✅ <b>Вы уже зарегистрированы</b>",
+		ES: "\n\n✅ <b>Ya estás apuntado</b>",
+		EN: "\n\n✅ <b>You are already registered</b>",
+	},
+	"event.register": {
+		RU: "📝 Записаться",
+		ES: "📝 Apuntarme",
+		EN: "📝 Sign up",
+	},
+	"inline.open_bot": {
+		RU: "🤖 Открыть в боте",
+		ES: "🤖 Abrir en el bot",
+		EN: "🤖 Open in the bot",
+	},
+	"register.closed": {
+		RU: "Регистрация на это событие закрыта.",
+		ES: "La inscripción para esta partida está cerrada.",
+		EN: "Registration for this game is closed.",
+	},
+	"register.failed": {
+		RU: "Не удалось зарегистрироваться. Попробуй ещё раз позже.",
+		ES: "No se pudo completar la inscripción. Inténtalo más tarde.",
+		EN: "Registration failed. Please try again later.",
+	},
+	"register.success": {
+		RU: "✅ Ты успешно зарегистрирован на событие!",
+		ES: "✅ ¡Te has apuntado a la partida!",
+		EN: "✅ You're registered for the game!",
+	},
+	"register.membership": {
+		RU: "\n🎫 Игра списана с абонемента.",
+		ES: "\n🎫 La partida se ha descontado de tu bono.",
+		EN: "\n🎫 The game was charged to your pass.",
+	},
+	"register.already": {
+		RU: "Ты уже записан на эту игру.",
+		ES: "Ya estás apuntado a esta partida.",
+		EN: "You're already signed up for this game.",
+	},
+	"register.toast": {
+		RU: "Регистрация успешна!",
+		ES: "¡Inscripción completada!",
+		EN: "Registered!",
+	},
+	"register.by_admin": {
+		RU: "✅ Администратор записал тебя на игру <b>%s</b> (%s). Если не сможешь прийти, отмени запись в /my.",
+		ES: "✅ Un administrador te ha apuntado a la partida <b>%s</b> (%s). Si no puedes venir, cancela en /my.",
+		EN: "✅ An admin signed you up for <b>%s</b> (%s). If you can't make it, cancel in /my.",
+	},
+	"cancel.failed": {
+		RU: "Не удалось отменить регистрацию.",
+		ES: "No se pudo cancelar la inscripción.",
+		EN: "Couldn't cancel the registration.",
+	},
+	"cancel.done": {
+		RU: "❌ Регистрация отменена.",
+		ES: "❌ Inscripción cancelada.",
+		EN: "❌ Registration cancelled.",
+	},
+
+	// Постоянная запись
+	"series.friday": {
+		RU: "Пятница",
+		ES: "Viernes",
+		EN: "Friday",
+	},
+	"series.saturday": {
+		RU: "Суббота",
+		ES: "Sábado",
+		EN: "Saturday",
+	},
+	"series.sunday": {
+		RU: "Воскресенье",
+		ES: "Domingo",
+		EN: "Sunday",
+	},
+	"subscription.text": {
+		RU: "🔁 <b>Постоянная запись</b>\n\nВыбери игры, на которые тебя записывать каждую неделю. Запись происходит, как только появляются игры недели — раньше общей записи, в порядке подписки. Неделю можно пропустить кнопкой под сообщением о записи, а подписку — приостановить.",
+		ES: "🔁 <b>Inscripción permanente</b>\n\nElige las partidas a las que apuntarte cada semana. Te apuntamos en cuanto salen las partidas de la semana, antes de la inscripción general y por orden de suscripción. Puedes saltarte una semana con el botón del mensaje de inscripción o pausar la suscripción.",
+		EN: "🔁 <b>Standing sign-up</b>\n\nChoose the games you want to be signed up for every week. You're signed up as soon as the week's games are created, before general registration opens, in order of subscription. You can skip a week with the button under the sign-up message or pause the subscription.",
+	},
+	"subscription.pause": {
+		RU: "⏸ Пауза",
+		ES: "⏸ Pausar",
+		EN: "⏸ Pause",
+	},
+	"subscription.resume": {
+		RU: "▶️ Продолжить",
+		ES: "▶️ Reanudar",
+		EN: "▶️ Resume",
+	},
+	"subscription.skip_week": {
+		RU: "🙅 Не смогу на этой неделе",
+		ES: "🙅 No puedo esta semana",
+		EN: "🙅 Can't make it this week",
+	},
+	"subscription.registered": {
+		RU: "🔁 По постоянной записи ты записан на игру <b>%s</b>: %s.\nЕсли не сможешь прийти, нажми кнопку ниже — место освободится для других.",
+		ES: "🔁 Por tu inscripción permanente estás apuntado a <b>%s</b>: %s.\nSi no puedes venir, pulsa el botón de abajo para liberar la plaza.",
+		EN: "🔁 Your standing sign-up got you a spot at <b>%s</b>: %s.\nIf you can't make it, tap the button below to free the spot for others.",
+	},
+	"subscription.no_seat": {
+		RU: "😔 На игру <b>%s</b> (%s) места по постоянной записи закончились. Запишись сам в /events, когда откроется общая запись.",
+		ES: "😔 Se han agotado las plazas de inscripción permanente para <b>%s</b> (%s). Apúntate en /events cuando se abra la inscripción general.",
+		EN: "😔 Standing sign-up spots for <b>%s</b> (%s) ran out. Sign up yourself in /events once general registration opens.",
+	},
+	"subscription.paused": {
+		RU: "⏸ Постоянная запись на игры «%s» приостановлена. Возобновить: /subscriptions",
+		ES: "⏸ Inscripción permanente a «%s» en pausa. Para reanudarla: /subscriptions",
+		EN: "⏸ Standing sign-up for \"%s\" is paused. To resume: /subscriptions",
+	},
+	"subscription.save_failed": {
+		RU: "Не удалось сохранить подписку. Попробуй ещё раз позже.",
+		ES: "No se pudo guardar la suscripción. Inténtalo más tarde.",
+		EN: "Couldn't save the subscription. Please try again later.",
+	},
+
+	// Профиль
+	"profile.text": {
+		RU: "Твой профиль:\n\n<b>Имя:</b> %s\n<b>Ник:</b> %s\n<b>Телефон:</b> %s",
+		ES: "Tu perfil:\n\n<b>Nombre:</b> %s\n<b>Apodo:</b> %s\n<b>Teléfono:</b> %s",
+		EN: "Your profile:\n\n<b>Name:</b> %s\n<b>Nickname:</b> %s\n<b>Phone:</b> %s",
+	},
+	"profile.phone_none": {
+		RU: "не указан",
+		ES: "no indicado",
+		EN: "not set",
+	},
+	"profile.btn_name": {
+		RU: "✏️ Имя",
+		ES: "✏️ Nombre",
+		EN: "✏️ Name",
+	},
+	"profile.btn_nickname": {
+		RU: "✏️ Ник",
+		ES: "✏️ Apodo",
+		EN: "✏️ Nickname",
+	},
+	"profile.btn_phone": {
+		RU: "📞 Телефон",
+		ES: "📞 Teléfono",
+		EN: "📞 Phone",
+	},
+	"profile.ask_name": {
+		RU: "Введи новое <b>имя</b> или /cancel, чтобы отменить:",
+		ES: "Escribe tu nuevo <b>nombre</b> o /cancel para cancelar:",
+		EN: "Enter your new <b>name</b> or /cancel to cancel:",
+	},
+	"profile.ask_nickname": {
+		RU: "Введи новый игровой <b>ник</b> или /cancel, чтобы отменить:",
+		ES: "Escribe tu nuevo <b>apodo</b> de juego o /cancel para cancelar:",
+		EN: "Enter your new game <b>nickname</b> or /cancel to cancel:",
+	},
+	"profile.share_phone": {
+		RU: "📞 Отправить номер",
+		ES: "📞 Enviar número",
+		EN: "📞 Share number",
+	},
+	"profile.ask_phone": {
+		RU: "Нажми кнопку ниже, чтобы поделиться номером телефона:",
+		ES: "Pulsa el botón de abajo para compartir tu número de teléfono:",
+		EN: "Tap the button below to share your phone number:",
+	},
+	"input.cancelled": {
+		RU: "Ввод отменён.",
+		ES: "Cancelado.",
+		EN: "Cancelled.",
+	},
+	"common.cancel": {
+		RU: "Отмена",
+		ES: "Cancelar",
+		EN: "Cancel",
+	},
+	"profile.retry_name": {
+		RU: "Не получилось: %v.\nВведи новое <b>имя</b> ещё раз:",
+		ES: "No ha funcionado: %v.\nEscribe tu nuevo <b>nombre</b> otra vez:",
+		EN: "That didn't work: %v.\nEnter your new <b>name</b> again:",
+	},
+	"profile.name_save_failed": {
+		RU: "Ошибка: не удалось сохранить имя.",
+		ES: "Error: no se pudo guardar el nombre.",
+		EN: "Error: couldn't save the name.",
+	},
+	"profile.retry_nickname": {
+		RU: "Не получилось: %v.\nВведи новый игровой <b>ник</b> ещё раз:",
+		ES: "No ha funcionado: %v.\nEscribe tu nuevo <b>apodo</b> de juego otra vez:",
+		EN: "That didn't work: %v.\nEnter your new game <b>nickname</b> again:",
+	},
+	"profile.nickname_save_failed": {
+		RU: "Ошибка: не удалось сохранить ник, возможно он уже занят.",
+		ES: "Error: no se pudo guardar el apodo, puede que ya esté ocupado.",
+		EN: "Error: couldn't save the nickname, it may already be taken.",
+	},
+	"profile.phone_cancelled": {
+		RU: "Изменение телефона отменено.",
+		ES: "Cambio de teléfono cancelado.",
+		EN: "Phone change cancelled.",
+	},
+	"profile.use_phone_button": {
+		RU: "Пожалуйста, воспользуйся кнопкой «📞 Отправить номер».",
+		ES: "Por favor, usa el botón «📞 Enviar número».",
+		EN: "Please use the “📞 Share number” button.",
+	},
+	"profile.own_phone_only": {
+		RU: "Можно отправить только свой собственный номер.",
+		ES: "Solo puedes enviar tu propio número.",
+		EN: "You can only share your own number.",
+	},
+	"profile.phone_save_failed": {
+		RU: "Ошибка: не удалось сохранить телефон.",
+		ES: "Error: no se pudo guardar el teléfono.",
+		EN: "Error: couldn't save the phone number.",
+	},
+	"profile.phone_saved": {
+		RU: "✅ Телефон сохранён.",
+		ES: "✅ Teléfono guardado.",
+		EN: "✅ Phone number saved.",
+	},
+	"profile.updated": {
+		RU: "✅ Профиль обновлён.",
+		ES: "✅ Perfil actualizado.",
+		EN: "✅ Profile updated.",
+	},
+
+	// Проверка имени и ника
+	"validate.field_name": {
+		RU: "имени",
+		ES: "del nombre",
+		EN: "of the name",
+	},
+	"validate.field_nickname": {
+		RU: "ника",
+		ES: "del apodo",
+		EN: "of the nickname",
+	},
+	"validate.nickname_taken": {
+		RU: "ник «%s» уже занят, попробуй другой",
+		ES: "el apodo «%s» ya está ocupado, prueba otro",
+		EN: "the nickname “%s” is already taken, try another one",
+	},
+	"validate.empty": {
+		RU: "нужен текст — стикеры и фото не подходят",
+		ES: "hace falta texto, los stickers y las fotos no sirven",
+		EN: "text is required, stickers and photos won't do",
+	},
+	"validate.command": {
+		RU: "текст не может начинаться с «/» — это похоже на команду",
+		ES: "el texto no puede empezar por «/», parece un comando",
+		EN: "the text can't start with “/”, it looks like a command",
+	},
+	"validate.length": {
+		RU: "длина %s — от %d до %d символов",
+		ES: "la longitud %s debe ser de %d a %d caracteres",
+		EN: "the length %s must be %d to %d characters",
+	},
+	"validate.chars": {
+		RU: "допустимы только буквы, цифры, пробел, дефис, точка, апостроф и подчёркивание",
+		ES: "solo se permiten letras, números, espacio, guion, punto, apóstrofo y guion bajo",
+		EN: "only letters, digits, space, hyphen, dot, apostrophe and underscore are allowed",
+	},
+	"validate.letter": {
+		RU: "нужна хотя бы одна буква",
+		ES: "hace falta al menos una letra",
+		EN: "at least one letter is required",
+	},
+	"validate.reserved": {
+		RU: "«%s» — зарезервированное слово, выбери другое",
+		ES: "«%s» es una palabra reservada, elige otra",
+		EN: "“%s” is a reserved word, choose another one",
+	},
+
+	// Настройки уведомлений
+	"settings.text": {
+		RU: "⚙️ <b>Настройки уведомлений</b>\n\nНапоминания об играх: за 24 часа %s, за 3 часа %s, за час %s\nЛичное сообщение о новых играх недели: %s\nТихие часы: %s\n\nВ тихие часы напоминания откладываются до их окончания.",
+		ES: "⚙️ <b>Notificaciones</b>\n\nRecordatorios de partidas: 24 horas antes %s, 3 horas antes %s, 1 hora antes %s\nMensaje privado con las partidas de la semana: %s\nHoras de silencio: %s\n\nDurante las horas de silencio los recordatorios se aplazan hasta que terminen.",
+		EN: "⚙️ <b>Notification settings</b>\n\nGame reminders: 24 hours before %s, 3 hours before %s, 1 hour before %s\nPrivate message about the week's new games: %s\nQuiet hours: %s\n\nDuring quiet hours reminders are postponed until they end.",
+	},
+	"settings.quiet_off": {
+		RU: "выключены",
+		ES: "desactivadas",
+		EN: "off",
+	},
+	"settings.quiet_range": {
+		RU: "с %02d:00 до %02d:00",
+		ES: "de %02d:00 a %02d:00",
+		EN: "from %02d:00 to %02d:00",
+	},
+	"settings.btn_24": {
+		RU: "24 ч",
+		ES: "24 h",
+		EN: "24 h",
+	},
+	"settings.btn_3": {
+		RU: "3 ч",
+		ES: "3 h",
+		EN: "3 h",
+	},
+	"settings.btn_1": {
+		RU: "1 ч",
+		ES: "1 h",
+		EN: "1 h",
+	},
+	"settings.btn_none": {
+		RU: "🔕 Без напоминаний",
+		ES: "🔕 Sin recordatorios",
+		EN: "🔕 No reminders",
+	},
+	"settings.btn_week": {
+		RU: "Новые игры недели в личку",
+		ES: "Partidas de la semana por privado",
+		EN: "Week's new games by DM",
+	},
+	"settings.btn_quiet": {
+		RU: "🌙 Тихие часы",
+		ES: "🌙 Horas de silencio",
+		EN: "🌙 Quiet hours",
+	},
+	"settings.ask_quiet": {
+		RU: "Введи тихие часы в формате <code>23-9</code> (с 23:00 до 09:00) или <code>off</code>, чтобы их выключить:",
+		ES: "Escribe las horas de silencio con el formato <code>23-9</code> (de 23:00 a 09:00) u <code>off</code> para desactivarlas:",
+		EN: "Enter quiet hours as <code>23-9</code> (from 23:00 to 09:00) or <code>off</code> to turn them off:",
+	},
+	"settings.save_failed": {
+		RU: "Ошибка: не удалось сохранить настройки.",
+		ES: "Error: no se pudieron guardar los ajustes.",
+		EN: "Error: couldn't save the settings.",
+	},
+	"settings.quiet_invalid": {
+		RU: "Не понял. Введи тихие часы в формате <code>23-9</code> или <code>off</code>:",
+		ES: "No lo he entendido. Escribe las horas de silencio con el formato <code>23-9</code> u <code>off</code>:",
+		EN: "I didn't get that. Enter quiet hours as <code>23-9</code> or <code>off</code>:",
+	},
+	"settings.quiet_range_invalid": {
+		RU: "Часы должны быть от 0 до 23 и не совпадать. Попробуй ещё раз:",
+		ES: "Las horas deben ir de 0 a 23 y no coincidir. Inténtalo otra vez:",
+		EN: "Hours must be from 0 to 23 and differ. Try again:",
+	},
+
+	// Персональные данные
+	"mydata.failed": {
+		RU: "Не удалось собрать данные. Возможно, ты ещё не зарегистрирован — нажми /start.",
+		ES: "No se pudieron reunir los datos. Puede que aún no estés registrado: pulsa /start.",
+		EN: "Couldn't collect your data. Maybe you're not registered yet — tap /start.",
+	},
+	"mydata.file_failed": {
+		RU: "Ошибка: не удалось подготовить файл.",
+		ES: "Error: no se pudo preparar el archivo.",
+		EN: "Error: couldn't prepare the file.",
+	},
+	"mydata.caption": {
+		RU: "Все данные, которые клуб хранит о тебе.",
+		ES: "Todos los datos que el club guarda sobre ti.",
+		EN: "All the data the club keeps about you.",
+	},
+	"forget.confirm": {
+		RU: "Ты действительно хочешь удалить свои персональные данные?\n\nИмя, ник, телефон и Telegram-аккаунт будут стёрты из базы и таблиц регистраций. Общая статистика игр сохранится в обезличенном виде.\nЭто действие нельзя отменить.",
+		ES: "¿Seguro que quieres borrar tus datos personales?\n\nTu nombre, apodo, teléfono y cuenta de Telegram se eliminarán de la base de datos y de las hojas de inscripción. Las estadísticas de partidas se conservarán de forma anónima.\nEsta acción no se puede deshacer.",
+		EN: "Do you really want to delete your personal data?\n\nYour name, nickname, phone and Telegram account will be erased from the database and registration sheets. Game statistics will be kept anonymously.\nThis can't be undone.",
+	},
+	"forget.yes": {
+		RU: "🗑 Да, удалить",
+		ES: "🗑 Sí, borrar",
+		EN: "🗑 Yes, delete",
+	},
+	"forget.cancelled": {
+		RU: "Удаление данных отменено.",
+		ES: "Borrado de datos cancelado.",
+		EN: "Data deletion cancelled.",
+	},
+	"forget.failed": {
+		RU: "Ошибка: не удалось удалить данные.",
+		ES: "Error: no se pudieron borrar los datos.",
+		EN: "Error: couldn't delete the data.",
+	},
+	"forget.done": {
+		RU: "✅ Твои персональные данные удалены. Спасибо, что играл с нами!",
+		ES: "✅ Tus datos personales se han borrado. ¡Gracias por jugar con nosotros!",
+		EN: "✅ Your personal data has been deleted. Thanks for playing with us!",
+	},
+
+	// Уведомления. Тексты напоминаний — редактируемые шаблоны в пакете render (/templates)
+	"newweek.title": {
+		RU: "Открыта запись на игры этой недели:\n",
+		ES: "Ya puedes apuntarte a las partidas de esta semana:\n",
+		EN: "Registration for this week's games is open:\n",
+	},
+	"newweek.footer": {
+		RU: "\n\nЗаписаться: /events",
+		ES: "\n\nApuntarse: /events",
+		EN: "\n\nSign up: /events",
+	},
+	"membership.expiring": {
+		RU: "Напоминание! Твой абонемент «%s» действует до %s.",
+		ES: "¡Recordatorio! Tu bono «%s» es válido hasta el %s.",
+		EN: "Reminder! Your “%s” pass is valid until %s.",
+	},
+	"membership.games_used": {
+		RU: "\nИспользовано игр: %d из %d.",
+		ES: "\nPartidas usadas: %d de %d.",
+		EN: "\nGames used: %d of %d.",
+	},
+	"membership.extend_hint": {
+		RU: "\nЧтобы продлить абонемент, напиши организаторам клуба 🙌",
+		ES: "\nPara renovar el bono, escribe a los organizadores del club 🙌",
+		EN: "\nTo renew your pass, message the club organizers 🙌",
+	},
+	"membership.issued": {
+		RU: "🎫 Тебе выдан абонемент «%s» до %s. Регистрации на игры будут списываться с него автоматически.",
+		ES: "🎫 Tienes un bono «%s» hasta el %s. Tus inscripciones se descontarán de él automáticamente.",
+		EN: "🎫 You've been given a “%s” pass until %s. Game registrations will be charged to it automatically.",
+	},
+	"membership.extended": {
+		RU: "🎫 Твой абонемент «%s» продлён до %s.",
+		ES: "🎫 Tu bono «%s» se ha renovado hasta el %s.",
+		EN: "🎫 Your “%s” pass has been extended until %s.",
+	},
+}
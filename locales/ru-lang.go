@@ -6,7 +6,7 @@ import (
 )
 
 var ruMonths = []string{
-	"января", "февраля", "марта", "апреля", "майя", "июня",
+	"января", "февраля", "марта", "апреля", "мая", "июня",
 	"июля", "августа", "сентября", "октября", "ноября", "декабря",
 }
 var ruWeekdays = []string{
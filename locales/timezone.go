@@ -0,0 +1,60 @@
+package locales
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // на Raspberry Pi может не быть системной базы часовых поясов
+)
+
+const DefaultTimezone = "Europe/Madrid"
+
+// Часовой пояс клуба. Даты игр хранятся в timestamptz, а вводятся, показываются
+// и планируются в этом поясе, поэтому переход на летнее время ничего не сдвигает
+var ClubLocation = mustLoadLocation(DefaultTimezone)
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// Задать часовой пояс клуба (CLUB_TIMEZONE). Пустое имя оставляет пояс по умолчанию
+func SetTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("SetTimezone error: %v", err)
+	}
+	ClubLocation = loc
+	return nil
+}
+
+// Момент времени в часовом поясе клуба
+func ClubTime(t time.Time) time.Time {
+	return t.In(ClubLocation)
+}
+
+// Текущее время в часовом поясе клуба
+func Now() time.Time {
+	return time.Now().In(ClubLocation)
+}
+
+// Полночь дня, в который попадает t, по часам клуба
+func StartOfDay(t time.Time) time.Time {
+	t = t.In(ClubLocation)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, ClubLocation)
+}
+
+// Начало сегодняшнего дня по часам клуба
+func Today() time.Time {
+	return StartOfDay(time.Now())
+}
+
+// Разобрать дату и время, введённые по часам клуба
+func ParseClubTime(layout, value string) (time.Time, error) {
+	return time.ParseInLocation(layout, value, ClubLocation)
+}
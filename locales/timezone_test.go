@@ -0,0 +1,79 @@
+package locales
+
+import (
+	"testing"
+	"time"
+)
+
+func madrid(t *testing.T) {
+	t.Helper()
+	if err := SetTimezone(DefaultTimezone); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClubTimeAcrossDST(t *testing.T) {
+	madrid(t)
+	for _, tc := range []struct {
+		utc  time.Time
+		want string
+	}{
+		// 29 марта 2026: в 01:00 UTC часы переводятся с 02:00 на 03:00
+		{time.Date(2026, 3, 29, 0, 59, 0, 0, time.UTC), "2026-03-29 01:59 +0100"},
+		{time.Date(2026, 3, 29, 1, 0, 0, 0, time.UTC), "2026-03-29 03:00 +0200"},
+		// 25 октября 2026: в 01:00 UTC часы переводятся с 03:00 на 02:00
+		{time.Date(2026, 10, 25, 0, 59, 0, 0, time.UTC), "2026-10-25 02:59 +0200"},
+		{time.Date(2026, 10, 25, 1, 0, 0, 0, time.UTC), "2026-10-25 02:00 +0100"},
+	} {
+		if got := ClubTime(tc.utc).Format("2006-01-02 15:04 -0700"); got != tc.want {
+			t.Errorf("ClubTime(%v) = %s, want %s", tc.utc, got, tc.want)
+		}
+	}
+}
+
+func TestParseClubTimeAcrossDST(t *testing.T) {
+	madrid(t)
+	// Игра в 18:30 по часам клуба до и после перехода — разное время в UTC
+	for _, tc := range []struct {
+		value string
+		utc   string
+	}{
+		{"2026-03-27 18:30", "2026-03-27 17:30"},
+		{"2026-03-29 18:30", "2026-03-29 16:30"},
+		{"2026-10-23 18:30", "2026-10-23 16:30"},
+		{"2026-10-25 18:30", "2026-10-25 17:30"},
+	} {
+		got, err := ParseClubTime("2006-01-02 15:04", tc.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := got.UTC().Format("2006-01-02 15:04"); s != tc.utc {
+			t.Errorf("ParseClubTime(%s) = %s UTC, want %s", tc.value, s, tc.utc)
+		}
+	}
+}
+
+func TestStartOfDayAcrossDST(t *testing.T) {
+	madrid(t)
+	for _, tc := range []struct {
+		utc   time.Time
+		start string
+		hours float64
+	}{
+		// День перехода на летнее время короче на час, на зимнее — длиннее
+		{time.Date(2026, 3, 29, 12, 0, 0, 0, time.UTC), "2026-03-28 23:00", 23},
+		{time.Date(2026, 10, 25, 12, 0, 0, 0, time.UTC), "2026-10-24 22:00", 25},
+		// 23:30 UTC 24 октября — уже 25 октября в Мадриде
+		{time.Date(2026, 10, 24, 23, 30, 0, 0, time.UTC), "2026-10-24 22:00", 25},
+		{time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC), "2026-06-30 22:00", 24},
+	} {
+		start := StartOfDay(tc.utc)
+		if s := start.UTC().Format("2006-01-02 15:04"); s != tc.start {
+			t.Errorf("StartOfDay(%v) = %s UTC, want %s", tc.utc, s, tc.start)
+		}
+		next := StartOfDay(start.AddDate(0, 0, 1))
+		if h := next.Sub(start).Hours(); h != tc.hours {
+			t.Errorf("day of %v lasts %vh, want %vh", tc.utc, h, tc.hours)
+		}
+	}
+}
@@ -1,23 +1,58 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"laverdad-bot/bot"
 	"laverdad-bot/db"
 	googleapi "laverdad-bot/google-api"
+	"laverdad-bot/locales"
+	"laverdad-bot/sender"
+	"laverdad-bot/server"
 	"laverdad-bot/services"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+const (
+	startupAttempts   = 10
+	startupRetryDelay = 3 * time.Second
+	shutdownTimeout   = 30 * time.Second
+)
+
 func main() {
+	// SIGTERM приходит от systemd при перезапуске сервиса в deploy.sh
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// Отмена с причиной — остановка из-за сбоя, например HTTP-сервера вебхука
+	ctx, fail := context.WithCancelCause(ctx)
+	defer fail(nil)
+
+	// Часовой пояс клуба, по умолчанию Europe/Madrid
+	if err := locales.SetTimezone(os.Getenv("CLUB_TIMEZONE")); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Часовой пояс клуба: %s", locales.ClubLocation)
+
 	token := os.Getenv("TELEGRAM_TOKEN")
 	if token == "" {
 		log.Fatal("TELEGRAM_TOKEN не задан")
 	}
 
-	botAPI, err := tgbotapi.NewBotAPI(token)
+	var botAPI *tgbotapi.BotAPI
+	err := waitReady(ctx, "Telegram", func() error {
+		var err error
+		botAPI, err = tgbotapi.NewBotAPI(token)
+		return err
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -26,21 +61,164 @@ func main() {
 
 	log.Printf("Бот запущен: %s", botAPI.Self.UserName)
 
+	sender.Init(botAPI)
+
 	db.InitDB(os.Getenv("DATABASE_URL"))
 
+	bot.InitCallbacks(os.Getenv("CALLBACK_SECRET"))
+
 	googleapi.InitSheetService()
+	// Без таблицы бот работает, поэтому недоступность Sheets не останавливает запуск
+	err = waitReady(ctx, "Google Sheets", func() error {
+		pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		return googleapi.Ping(pingCtx)
+	})
+	if err != nil {
+		log.Println("WARNING: Google Sheets недоступен:", err)
+	}
 
 	// Запуск горутины уведомлений
-	go bot.StartNotifications(botAPI)
+	var notifications sync.WaitGroup
+	notifications.Add(1)
+	go func() {
+		defer notifications.Done()
+		bot.StartNotifications(ctx, botAPI)
+	}()
 
 	services.InitCron(botAPI)
 
+	var updates tgbotapi.UpdatesChannel
+	if os.Getenv("BOT_MODE") == "webhook" {
+		updates = startWebhook(ctx, fail, botAPI)
+	} else {
+		updates = startPolling(ctx, botAPI)
+	}
+
+	dispatcher := bot.NewDispatcher(botAPI, 0)
+	for update := range updates {
+		dispatcher.Dispatch(update)
+	}
+
+	// Канал обновлений закрывается только после отмены ctx — начинаем остановку
+	log.Println("Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	dispatcher.Stop()
+	notifications.Wait()
+	services.StopCron(shutdownCtx)
+	if err := sender.Stop(shutdownCtx); err != nil {
+		log.Println("Outgoing messages were not delivered:", err)
+	}
+	if err := googleapi.Wait(shutdownCtx); err != nil {
+		log.Println("Pending Google Sheets writes were not flushed:", err)
+	}
+	db.CloseDB()
+	if err := context.Cause(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatal("Бот остановлен из-за ошибки: ", err)
+	}
+	log.Println("Бот остановлен")
+}
+
+// Повторять проверку готовности зависимости, пока она не пройдёт или не кончатся попытки
+func waitReady(ctx context.Context, name string, check func() error) error {
+	var err error
+	for attempt := 1; attempt <= startupAttempts; attempt++ {
+		if err = check(); err == nil {
+			log.Printf("%s is ready\n", name)
+			return nil
+		}
+		log.Printf("%s is not ready (attempt %d/%d): %v\n", name, attempt, startupAttempts, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(startupRetryDelay):
+		}
+	}
+	return err
+}
+
+func startPolling(ctx context.Context, botAPI *tgbotapi.BotAPI) tgbotapi.UpdatesChannel {
+	// getUpdates не работает, пока установлен вебхук
+	if err := server.DeleteWebhook(botAPI); err != nil {
+		log.Println(err)
+	}
+
 	updateConfig := tgbotapi.NewUpdate(0)
 	updateConfig.Timeout = 60
 
 	updates := botAPI.GetUpdatesChan(updateConfig)
 
-	for update := range updates {
-		go bot.HandleUpdate(botAPI, update)
+	// Канал закроется после завершения текущего long polling запроса
+	go func() {
+		<-ctx.Done()
+		botAPI.StopReceivingUpdates()
+	}()
+
+	return updates
+}
+
+// Режим вебхука: WEBHOOK_URL — публичный адрес (например, https://bot.example.com/telegram),
+// WEBHOOK_SECRET — секрет для заголовка X-Telegram-Bot-Api-Secret-Token,
+// HTTP_ADDR — адрес, который слушает сервер (по умолчанию :8080).
+// TLS_CERT_FILE и TLS_KEY_FILE нужны, только если TLS не завершается на reverse proxy.
+// Если сервер перестаёт работать, бот останавливается через fail.
+func startWebhook(ctx context.Context, fail context.CancelCauseFunc, botAPI *tgbotapi.BotAPI) tgbotapi.UpdatesChannel {
+	webhookURL := os.Getenv("WEBHOOK_URL")
+	secret := os.Getenv("WEBHOOK_SECRET")
+	if webhookURL == "" || secret == "" {
+		log.Fatal("Для BOT_MODE=webhook нужны WEBHOOK_URL и WEBHOOK_SECRET")
+	}
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		log.Fatalf("Неверный WEBHOOK_URL: %v", err)
 	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	addr := os.Getenv("HTTP_ADDR")
+	if addr == "" {
+		addr = ":8080"
+	}
+
+	updates := make(chan tgbotapi.Update, botAPI.Buffer)
+	srv := server.New(addr)
+	srv.WithTLS(os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE"))
+	srv.Handle(path, server.WebhookHandler(secret, updates))
+	srv.Handle("/healthz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serverErrs := srv.Start()
+	go func() {
+		if err, ok := <-serverErrs; ok {
+			fail(err)
+		}
+	}()
+
+	if err := server.SetWebhook(botAPI, webhookURL, secret); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Вебхук зарегистрирован: %s\n", webhookURL)
+
+	// При остановке снимаем вебхук и закрываем канал обновлений
+	go func() {
+		<-ctx.Done()
+
+		if err := server.DeleteWebhook(botAPI); err != nil {
+			log.Println(err)
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Println("HTTP server shutdown error:", err)
+		}
+		close(updates)
+	}()
+
+	return updates
 }
@@ -0,0 +1,326 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"log"
+	"sync"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+)
+
+// Шаблоны, которые администраторы меняют из Telegram (/templates) без передеплоя.
+// Текст по умолчанию задан в коде, изменённый хранится в таблице message_templates.
+// Каждый шаблон получает данные своего типа, поэтому опечатка в плейсхолдере
+// обнаруживается при сохранении, а не при отправке сообщения
+
+// Изменённый шаблон из базы; в тестах подменяется
+var storedTemplate = db.GetMessageTemplate
+
+// Донат за одну игру
+var Donation = fmt.Sprintf("%d€", db.DonationEUR)
+
+// Событие в данных шаблона
+type EventVars struct {
+	ID        int
+	Title     string
+	Date      string        // «пятница, 24 октября» на языке получателя
+	DateShort string        // «24 октября (пт)»
+	Time      string        // «19:30»
+	Venue     template.HTML // место, может содержать ссылку на карту
+	Donation  string
+	Players   int // сколько игроков записалось, заполняется только в анонсе недели
+}
+
+type PlayerVars struct {
+	Name     string
+	Nickname string // пустой у гостя без Telegram
+	GuestOf  string // ник игрока, который привёл гостя
+}
+
+// Анонс записи на неделю в группе клуба
+type AnnouncementData struct {
+	Bot    string
+	Events []EventVars
+}
+
+// Пост о кворуме в группе клуба
+type QuorumData struct {
+	Event   EventVars
+	Players []PlayerVars
+	Count   int
+}
+
+// Описание события и напоминания о нём
+type EventData struct {
+	Event EventVars
+}
+
+func Event(e db.Event, lang locales.Lang) EventVars {
+	return EventVars{
+		ID:        e.ID,
+		Title:     e.Title,
+		Date:      locales.FormatDate(lang, e.StartsAt),
+		DateShort: locales.FormatDateShort(lang, e.StartsAt),
+		Time:      e.StartsAt.Format("15:04"),
+		Venue:     template.HTML(e.Location),
+		Donation:  Donation,
+	}
+}
+
+func Players(participants []db.Participant) []PlayerVars {
+	players := make([]PlayerVars, len(participants))
+	for i, p := range participants {
+		players[i] = PlayerVars{Name: p.Name, Nickname: p.Nickname, GuestOf: p.GuestOf}
+	}
+	return players
+}
+
+type Named struct {
+	Name         string
+	Title        string
+	Placeholders string
+	Langs        []locales.Lang
+	defaults     map[locales.Lang]string
+	sample       any
+
+	mu    sync.Mutex
+	cache map[locales.Lang]*template.Template
+}
+
+var (
+	sampleEvent = EventVars{
+		ID:        12,
+		Title:     "Клубные игры",
+		Date:      "пятница, 24 октября",
+		DateShort: "24 октября (пт)",
+		Time:      "19:30",
+		Venue:     `🎙 Студия: <a href="https://maps.app.goo.gl/K21A6KPB65FbNbcP8">Calle Conejito de Málaga, 18</a>`,
+		Donation:  Donation,
+	}
+	eventPlaceholders = "{{.Event.Title}}, {{.Event.Date}}, {{.Event.DateShort}}, {{.Event.Time}}, {{.Event.Venue}}, {{.Event.Donation}}"
+)
+
+var Announcement = &Named{
+	Name:         "announcement",
+	Title:        "анонс записи на неделю в группе",
+	Placeholders: "{{.Bot}} — имя бота, {{range .Events}}{{.Title}} {{.DateShort}} {{.Time}} {{.Players}}{{end}} — игры недели и число записавшихся",
+	Langs:        []locales.Lang{locales.RU},
+	defaults: map[locales.Lang]string{
+		locales.RU: `Мирный привет городу, соберёмся играть в 🔴 мафию ⚫ на этой неделе?
+Обратите внимание, что место и время отличаются по дням.
+Для записи на игры перейдите в бот @{{.Bot}}`,
+	},
+	sample: AnnouncementData{Bot: "mafia_appointment_bot", Events: []EventVars{sampleEvent}},
+}
+
+var Quorum = &Named{
+	Name:         "quorum",
+	Title:        "пост о кворуме в группе",
+	Placeholders: eventPlaceholders + ", {{.Count}} — число игроков, {{range .Players}}{{.Name}} {{.Nickname}} {{.GuestOf}}{{end}} — список игроков (у гостей нет ника, GuestOf — ник пригласившего)",
+	Langs:        []locales.Lang{locales.RU},
+	defaults: map[locales.Lang]string{
+		locales.RU: `Есть кворум!
+
+{{.Event.Title}}
+🗓 {{.Event.DateShort}}🕐 {{.Event.Time}}.
+📌 {{.Event.Venue}}
+💶 Донат на развитие клуба - {{.Event.Donation}} с человека.
+
+Постарайтесь не опоздать. Если что-то поменяется, обязательно напишите. Ждём! 🕵️‍♂️
+{{range $i, $p := .Players}}{{inc $i}}) {{if $p.Nickname}}@{{$p.Nickname}}{{else}}{{$p.Name}}{{if $p.GuestOf}} (гость @{{$p.GuestOf}}){{end}}{{end}}
+{{end}}`,
+	},
+	sample: QuorumData{Event: sampleEvent, Players: []PlayerVars{{Name: "Иван", Nickname: "ivan"}, {Name: "Мария", Nickname: "maria_m"}, {Name: "Пабло", GuestOf: "ivan"}}, Count: 3},
+}
+
+var EventDescription = &Named{
+	Name:         "event_description",
+	Title:        "описание еженедельной игры (/generate)",
+	Placeholders: eventPlaceholders,
+	Langs:        locales.Langs,
+	defaults: map[locales.Lang]string{
+		locales.RU: "Клубные игры (фанки). 4-5 игр по спортивной мафии в дружественной атмосфере.\n\n🗓️ {{.Event.Date}}\n⏳ {{.Event.Time}} - 23:00 \n📌 {{.Event.Venue}}\n💶 Донат на развитие клуба - {{.Event.Donation}} с человека.\n\nЕсли вы первый раз - ведущий расскажет правила и поможет влиться, во время игры будет делать небольшие комментарии 🤗\n",
+		locales.ES: "Partidas de club (funky). 4-5 partidas de mafia deportiva en un ambiente amistoso.\n\n🗓️ {{.Event.Date}}\n⏳ {{.Event.Time}} - 23:00 \n📌 {{.Event.Venue}}\n💶 Donativo para el club - {{.Event.Donation}} por persona.\n\nSi es tu primera vez, el presentador te explicará las reglas y te ayudará durante la partida 🤗\n",
+		locales.EN: "Club games (funky). 4-5 games of sports mafia in a friendly atmosphere.\n\n🗓️ {{.Event.Date}}\n⏳ {{.Event.Time}} - 23:00 \n📌 {{.Event.Venue}}\n💶 Donation to the club - {{.Event.Donation}} per person.\n\nIf it's your first time, the host will explain the rules and help you along during the game 🤗\n",
+	},
+	sample: EventData{Event: sampleEvent},
+}
+
+var Reminder24 = &Named{
+	Name:         "reminder24",
+	Title:        "напоминание за сутки",
+	Placeholders: eventPlaceholders,
+	Langs:        locales.Langs,
+	defaults: map[locales.Lang]string{
+		locales.RU: "Напоминание! Завтра в {{.Event.Time}} начнется: {{.Event.Title}}",
+		locales.ES: "¡Recordatorio! Mañana a las {{.Event.Time}} empieza: {{.Event.Title}}",
+		locales.EN: "Reminder! Tomorrow at {{.Event.Time}}: {{.Event.Title}}",
+	},
+	sample: EventData{Event: sampleEvent},
+}
+
+var Reminder3 = &Named{
+	Name:         "reminder3",
+	Title:        "напоминание за 3 часа",
+	Placeholders: eventPlaceholders,
+	Langs:        locales.Langs,
+	defaults: map[locales.Lang]string{
+		locales.RU: "Напоминание! Через 3 часа начнется: {{.Event.Title}}",
+		locales.ES: "¡Recordatorio! Dentro de 3 horas empieza: {{.Event.Title}}",
+		locales.EN: "Reminder! Starting in 3 hours: {{.Event.Title}}",
+	},
+	sample: EventData{Event: sampleEvent},
+}
+
+var Reminder1 = &Named{
+	Name:         "reminder1",
+	Title:        "напоминание за час",
+	Placeholders: eventPlaceholders,
+	Langs:        locales.Langs,
+	defaults: map[locales.Lang]string{
+		locales.RU: "Напоминание! Через час начнется: {{.Event.Title}}",
+		locales.ES: "¡Recordatorio! Dentro de una hora empieza: {{.Event.Title}}",
+		locales.EN: "Reminder! Starting in an hour: {{.Event.Title}}",
+	},
+	sample: EventData{Event: sampleEvent},
+}
+
+// Все редактируемые шаблоны в порядке вывода в /templates
+var Templates = []*Named{Announcement, Quorum, EventDescription, Reminder24, Reminder3, Reminder1}
+
+func Lookup(name string) (*Named, bool) {
+	for _, n := range Templates {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+func (n *Named) HasLang(lang locales.Lang) bool {
+	for _, l := range n.Langs {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Named) Default(lang locales.Lang) string {
+	if src, ok := n.defaults[lang]; ok {
+		return src
+	}
+	return n.defaults[locales.DefaultLang]
+}
+
+// Текущий текст шаблона и признак того, что он изменён администратором
+func (n *Named) Source(lang locales.Lang) (string, bool) {
+	body, ok, err := storedTemplate(n.Name, string(lang))
+	if err != nil {
+		log.Println(err)
+	}
+	if ok {
+		return body, true
+	}
+	return n.Default(lang), false
+}
+
+// Выполнить шаблон на языке lang. Если изменённый шаблон сломан, используется текст по умолчанию
+func (n *Named) Render(lang locales.Lang, data any) string {
+	if !n.HasLang(lang) {
+		lang = locales.DefaultLang
+	}
+	text, err := execute(n.load(lang), data)
+	if err != nil {
+		log.Printf("render: template %s/%s error: %v\n", n.Name, lang, err)
+		text, err = execute(template.Must(parse(n.Name, n.Default(lang))), data)
+		if err != nil {
+			log.Printf("render: default template %s/%s error: %v\n", n.Name, lang, err)
+		}
+	}
+	return text
+}
+
+// Выполнить новый текст шаблона на примере данных. Ошибка означает, что сохранять его нельзя
+func (n *Named) Preview(src string) (string, error) {
+	tmpl, err := parse(n.Name, src)
+	if err != nil {
+		return "", err
+	}
+	return execute(tmpl, n.sample)
+}
+
+func (n *Named) Save(lang locales.Lang, src string, updatedBy int64) error {
+	if _, err := n.Preview(src); err != nil {
+		return err
+	}
+	if err := db.SaveMessageTemplate(n.Name, string(lang), src, updatedBy); err != nil {
+		return err
+	}
+	n.forget(lang)
+	return nil
+}
+
+// Вернуть текст по умолчанию
+func (n *Named) Reset(lang locales.Lang) error {
+	if err := db.DeleteMessageTemplate(n.Name, string(lang)); err != nil {
+		return err
+	}
+	n.forget(lang)
+	return nil
+}
+
+func (n *Named) load(lang locales.Lang) *template.Template {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if tmpl, ok := n.cache[lang]; ok {
+		return tmpl
+	}
+	src := n.Default(lang)
+	body, ok, dbErr := storedTemplate(n.Name, string(lang))
+	if ok {
+		src = body
+	}
+	tmpl, err := parse(n.Name, src)
+	if err != nil {
+		log.Printf("render: template %s/%s parse error: %v\n", n.Name, lang, err)
+		tmpl = template.Must(parse(n.Name, n.Default(lang)))
+	}
+	// База недоступна: отправляем текст по умолчанию, но не запоминаем его,
+	// иначе изменённый шаблон не вернётся до перезапуска
+	if dbErr != nil {
+		log.Println(dbErr)
+		return tmpl
+	}
+	if n.cache == nil {
+		n.cache = map[locales.Lang]*template.Template{}
+	}
+	n.cache[lang] = tmpl
+	return tmpl
+}
+
+func (n *Named) forget(lang locales.Lang) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.cache, lang)
+}
+
+func parse(name, src string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка в шаблоне: %v", err)
+	}
+	return tmpl, nil
+}
+
+func execute(tmpl *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
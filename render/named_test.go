@@ -0,0 +1,112 @@
+package render
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+)
+
+// Подменить базу шаблонов на время теста и сбросить кеш
+func stubTemplates(t *testing.T, lookup func(name, lang string) (string, bool, error)) {
+	t.Helper()
+	saved := storedTemplate
+	storedTemplate = lookup
+	clearCache := func() {
+		for _, n := range Templates {
+			n.mu.Lock()
+			n.cache = nil
+			n.mu.Unlock()
+		}
+	}
+	clearCache()
+	t.Cleanup(func() {
+		storedTemplate = saved
+		clearCache()
+	})
+}
+
+func noStoredTemplates(name, lang string) (string, bool, error) {
+	return "", false, nil
+}
+
+func TestNamedDefaults(t *testing.T) {
+	stubTemplates(t, noStoredTemplates)
+
+	participants := []db.Participant{
+		{TelegramID: 101, Name: "Иван", Nickname: "ivan_b"},
+		{TelegramID: 102, Name: "Мария <3", Nickname: "maria"},
+		{Name: "Пабло", GuestOf: "ivan_b"},
+	}
+	for _, n := range Templates {
+		for _, lang := range n.Langs {
+			event := Event(testEvent, lang)
+			var data any = EventData{Event: event}
+			switch n {
+			case Announcement:
+				event.Players = 5
+				data = AnnouncementData{Bot: "mafia_appointment_bot", Events: []EventVars{event}}
+			case Quorum:
+				data = QuorumData{Event: event, Players: Players(participants), Count: len(participants)}
+			}
+			golden(t, fmt.Sprintf("named_%s_%s", n.Name, lang), n.Render(lang, data))
+		}
+	}
+}
+
+func TestNamedPreviewSamples(t *testing.T) {
+	stubTemplates(t, noStoredTemplates)
+	for _, n := range Templates {
+		for _, lang := range n.Langs {
+			text, err := n.Preview(n.Default(lang))
+			if err != nil {
+				t.Errorf("%s/%s: %v", n.Name, lang, err)
+			}
+			if text != n.Render(lang, n.sample) {
+				t.Errorf("%s/%s: preview differs from render", n.Name, lang)
+			}
+		}
+	}
+}
+
+func TestNamedStoredTemplate(t *testing.T) {
+	stubTemplates(t, func(name, lang string) (string, bool, error) {
+		switch name + "/" + lang {
+		case "reminder1/es":
+			return "¡{{.Event.Title}} a las {{.Event.Time}}!", true, nil
+		case "reminder1/en":
+			// Несуществующее поле: при выполнении берётся текст по умолчанию
+			return "{{.Event.Nope}}", true, nil
+		}
+		return "", false, nil
+	})
+
+	data := EventData{Event: Event(testEvent, locales.ES)}
+	golden(t, "named_reminder1_es_custom", Reminder1.Render(locales.ES, data))
+
+	data = EventData{Event: Event(testEvent, locales.EN)}
+	golden(t, "named_reminder1_en", Reminder1.Render(locales.EN, data))
+}
+
+func TestNamedDoesNotCacheDatabaseErrors(t *testing.T) {
+	down := true
+	stubTemplates(t, func(name, lang string) (string, bool, error) {
+		if down {
+			return "", false, errors.New("connection refused")
+		}
+		return "Изменённый: {{.Event.Title}}", true, nil
+	})
+
+	data := EventData{Event: Event(testEvent, locales.RU)}
+	if got := Reminder24.Render(locales.RU, data); !strings.HasPrefix(got, "Напоминание! Завтра в 18:30") {
+		t.Errorf("while the database is down: got %q, want the default text", got)
+	}
+
+	down = false
+	if got := Reminder24.Render(locales.RU, data); !strings.HasPrefix(got, "Изменённый: ") {
+		t.Errorf("after the database is back: got %q, want the stored template", got)
+	}
+}
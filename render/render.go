@@ -0,0 +1,98 @@
+package render
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"strings"
+
+	"laverdad-bot/locales"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Все сообщения бота размечаются HTML: в нём, в отличие от Markdown, любое значение
+// можно надёжно экранировать, и ник с «_» или «*» больше не ломает сообщение.
+// Разрешённые Telegram теги: <b>, <i>, <u>, <s>, <code>, <pre>, <a href>.
+const ParseMode = tgbotapi.ModeHTML
+
+var escaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// Экранировать пользовательский текст для HTML-разметки
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Перевод из каталога сообщений. Тексты каталога — доверенная разметка, а аргументы экранируются
+func T(lang locales.Lang, key string, args ...any) string {
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			escaped[i] = Escape(v)
+		case error:
+			escaped[i] = Escape(v.Error())
+		case fmt.Stringer:
+			escaped[i] = Escape(v.String())
+		default:
+			escaped[i] = arg
+		}
+	}
+	return locales.T(lang, key, escaped...)
+}
+
+// Сообщение с HTML-разметкой
+func Message(chatID int64, html string) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, html)
+	msg.ParseMode = ParseMode
+	return msg
+}
+
+// Редактирование сообщения с HTML-разметкой
+func Edit(chatID int64, messageID int, html string) tgbotapi.EditMessageTextConfig {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, html)
+	edit.ParseMode = ParseMode
+	return edit
+}
+
+// Результат inline-запроса с HTML-разметкой
+func Article(id, title, html string) tgbotapi.InlineQueryResultArticle {
+	return tgbotapi.InlineQueryResultArticle{
+		Type:                "article",
+		ID:                  id,
+		Title:               title,
+		InputMessageContent: tgbotapi.InputTextMessageContent{Text: html, ParseMode: ParseMode},
+	}
+}
+
+// Template — шаблон сообщения на html/template: все подставляемые значения экранируются
+// автоматически. Разметку, которой можно доверять (описание события от администратора),
+// нужно явно пропустить через функцию markup
+type Template struct {
+	name string
+	tmpl *template.Template
+}
+
+var funcs = template.FuncMap{
+	// Доверенная HTML-разметка
+	"markup": func(s string) template.HTML { return template.HTML(s) },
+	// Ссылка на пользователя Telegram по id
+	"userURL":   func(id int64) template.URL { return template.URL(fmt.Sprintf("tg://user?id=%d", id)) },
+	"inc":       func(i int) int { return i + 1 },
+	"date":      locales.FormatDate,
+	"dateShort": locales.FormatDateShort,
+}
+
+// Разобрать шаблон. Ошибка в шаблоне — ошибка программиста, поэтому паника при запуске
+func New(name, src string) *Template {
+	return &Template{name: name, tmpl: template.Must(template.New(name).Funcs(funcs).Parse(src))}
+}
+
+// Выполнить шаблон. При ошибке она логируется, и возвращается пустая строка
+func (t *Template) Render(data any) string {
+	text, err := execute(t.tmpl, data)
+	if err != nil {
+		log.Printf("render: template %s error: %v\n", t.name, err)
+	}
+	return text
+}
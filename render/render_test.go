@@ -0,0 +1,87 @@
+package render
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+)
+
+// go test ./render -update перезаписывает эталоны в testdata
+var update = flag.Bool("update", false, "update golden files")
+
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run go test ./render -update)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s differs from %s:\n--- got ---\n%s\n--- want ---\n%s", name, path, got, want)
+	}
+}
+
+var testEvent = db.Event{
+	ID:       7,
+	Title:    `Клубные игры <b>&</b> "друзья"`,
+	Location: `🎙 Студия: <a href="https://maps.app.goo.gl/K21A6KPB65FbNbcP8">Calle Conejito de Málaga, 18</a>`,
+	StartsAt: time.Date(2026, 10, 23, 18, 30, 0, 0, locales.ClubLocation),
+}
+
+func TestEscape(t *testing.T) {
+	got := Escape(`<b>Tom & "Jerry"</b>`)
+	want := "&lt;b&gt;Tom &amp; &quot;Jerry&quot;&lt;/b&gt;"
+	if got != want {
+		t.Errorf("Escape = %s, want %s", got, want)
+	}
+}
+
+func TestTEscapesArguments(t *testing.T) {
+	var out string
+	for _, lang := range locales.Langs {
+		out += string(lang) + ": " + T(lang, "guest.added", "<i>Ann</i> & Bob") + "\n"
+		out += string(lang) + ": " + T(lang, "guest.retry_name", errors.New("имя <слишком> длинное")) + "\n"
+		out += string(lang) + ": " + T(lang, "guest.limit", 2) + "\n"
+	}
+	golden(t, "catalog", out)
+}
+
+func TestEventRegistrations(t *testing.T) {
+	type data struct {
+		Event                  db.Event
+		Link                   string
+		Registrations          []db.AdminRegistration
+		Memberships, Donations int
+	}
+	regs := []db.AdminRegistration{
+		{Name: "Иван <script>", Nickname: "ivan_b", TelegramID: 101, Membership: true},
+		{Name: "Мария", Nickname: "maria*m", TelegramID: 102, Blocked: true},
+		{Name: "Пабло", GuestOf: "ivan_b"},
+		{Name: "Хуан"},
+	}
+	link := "https://t.me/mafia_appointment_bot?start=ev_7"
+
+	golden(t, "event_registrations", EventRegistrations.Render(data{testEvent, link, regs, 1, 3}))
+	golden(t, "event_registrations_empty", EventRegistrations.Render(data{testEvent, link, nil, 0, 0}))
+}
+
+func TestMessageUsesHTML(t *testing.T) {
+	if msg := Message(1, "<b>x</b>"); msg.ParseMode != "HTML" {
+		t.Errorf("Message parse mode %q", msg.ParseMode)
+	}
+	if edit := Edit(1, 2, "<b>x</b>"); edit.ParseMode != "HTML" {
+		t.Errorf("Edit parse mode %q", edit.ParseMode)
+	}
+}
@@ -0,0 +1,9 @@
+package render
+
+// Список регистраций на событие для администратора.
+// Данные: Event db.Event, Link string, Registrations []db.AdminRegistration, Memberships, Donations int
+var EventRegistrations = New("event_registrations", `#{{.Event.ID}} {{.Event.Title}} — {{.Event.StartsAt.Format "02.01 15:04"}}
+🔗 {{.Link}}
+{{if not .Registrations}}Нет регистраций на мероприятие!{{else}}{{range .Registrations}}- {{if .TelegramID}}<a href="{{userURL .TelegramID}}">{{.Name}}  ({{.Nickname}})</a>{{else}}{{.Name}}{{if .GuestOf}} (гость @{{.GuestOf}}){{else}} (без Telegram){{end}}{{end}}{{if .Membership}} 🎫{{end}}{{if .Blocked}} 🚫 бот заблокирован, напоминаний не получит{{end}}
+{{end}}
+🎫 Абонементы: {{.Memberships}}, 💶 донаты: {{.Donations}}{{end}}`)
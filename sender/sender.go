@@ -0,0 +1,297 @@
+package sender
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"laverdad-bot/db"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Очередь исходящих сообщений для рассылок.
+// Telegram разрешает боту ~30 сообщений в секунду суммарно, не больше одного
+// сообщения в секунду в один чат и 20 сообщений в минуту в одну группу.
+// При превышении лимита приходит 429 с retry_after — такие сообщения
+// отправляются повторно после паузы, а не теряются.
+
+const (
+	globalInterval  = time.Second / 30
+	privateInterval = time.Second
+	groupInterval   = time.Minute / 20
+	maxAttempts     = 5
+	maxRateLimited  = 10
+	retryBaseDelay  = 2 * time.Second
+)
+
+type job struct {
+	chatID  int64
+	msg     tgbotapi.Chattable
+	onDone  func(tgbotapi.Message, error)
+	readyAt time.Time
+	attempt int
+	limited int
+	seq     int
+}
+
+type jobQueue []*job
+
+func (q jobQueue) Len() int { return len(q) }
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].readyAt.Equal(q[j].readyAt) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].readyAt.Before(q[j].readyAt)
+}
+func (q jobQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *jobQueue) Push(x any)   { *q = append(*q, x.(*job)) }
+func (q *jobQueue) Pop() any {
+	old := *q
+	n := len(old)
+	j := old[n-1]
+	*q = old[:n-1]
+	return j
+}
+
+var (
+	botAPI *tgbotapi.BotAPI
+
+	mu       sync.Mutex
+	queue    jobQueue
+	seq      int
+	nextSlot = map[int64]time.Time{}
+	wakeup   = make(chan struct{}, 1)
+	idle     = make(chan struct{})
+	stopping bool
+	inFlight int
+)
+
+func Init(bot *tgbotapi.BotAPI) {
+	botAPI = bot
+	go worker()
+}
+
+// Поставить сообщение в очередь. onDone (может быть nil) вызывается с итоговой ошибкой доставки
+func Enqueue(chatID int64, msg tgbotapi.Chattable, onDone func(error)) {
+	var done func(tgbotapi.Message, error)
+	if onDone != nil {
+		done = func(_ tgbotapi.Message, err error) { onDone(err) }
+	}
+	EnqueueMessage(chatID, msg, done)
+}
+
+// Как Enqueue, но onDone получает и отправленное сообщение — например, чтобы потом его отредактировать
+func EnqueueMessage(chatID int64, msg tgbotapi.Chattable, onDone func(tgbotapi.Message, error)) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if stopping {
+		log.Printf("sender: queue is stopped, message to %d dropped\n", chatID)
+		if onDone != nil {
+			go onDone(tgbotapi.Message{}, errors.New("sender is stopped"))
+		}
+		return
+	}
+
+	push(&job{chatID: chatID, msg: msg, onDone: onDone, readyAt: reserveSlot(chatID, time.Now())})
+}
+
+func Send(chatID int64, msg tgbotapi.Chattable) {
+	Enqueue(chatID, msg, nil)
+}
+
+// Дождаться отправки всех сообщений из очереди, но не дольше, чем позволяет ctx
+func Stop(ctx context.Context) error {
+	mu.Lock()
+	stopping = true
+	empty := len(queue) == 0 && inFlight == 0
+	mu.Unlock()
+	if empty {
+		return nil
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ближайшее время, когда в чат можно отправить следующее сообщение. Вызывать под mu
+func reserveSlot(chatID int64, now time.Time) time.Time {
+	interval := privateInterval
+	if chatID < 0 {
+		interval = groupInterval
+	}
+
+	slot := now
+	if next, ok := nextSlot[chatID]; ok && next.After(now) {
+		slot = next
+	}
+	nextSlot[chatID] = slot.Add(interval)
+	return slot
+}
+
+// Вызывать под mu
+func push(j *job) {
+	seq++
+	j.seq = seq
+	heap.Push(&queue, j)
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func worker() {
+	limiter := time.NewTicker(globalInterval)
+	defer limiter.Stop()
+
+	for {
+		j := nextJob()
+		<-limiter.C
+		deliver(j)
+
+		mu.Lock()
+		inFlight--
+		if stopping && len(queue) == 0 && inFlight == 0 {
+			close(idle)
+			mu.Unlock()
+			return
+		}
+		mu.Unlock()
+	}
+}
+
+// Дождаться задачи, время которой наступило
+func nextJob() *job {
+	for {
+		mu.Lock()
+		if len(queue) > 0 {
+			wait := time.Until(queue[0].readyAt)
+			if wait <= 0 {
+				j := heap.Pop(&queue).(*job)
+				inFlight++
+				mu.Unlock()
+				return j
+			}
+			mu.Unlock()
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-wakeup:
+				timer.Stop()
+			}
+			continue
+		}
+		// Очередь пуста — забываем чаты, лимиты которых уже не действуют
+		now := time.Now()
+		for chatID, next := range nextSlot {
+			if next.Before(now) {
+				delete(nextSlot, chatID)
+			}
+		}
+		mu.Unlock()
+		<-wakeup
+	}
+}
+
+func deliver(j *job) {
+	sent, err := botAPI.Send(j.msg)
+	if err == nil {
+		finish(j, sent, nil)
+		return
+	}
+
+	var tgErr *tgbotapi.Error
+	isAPIError := errors.As(err, &tgErr)
+
+	switch {
+	case isAPIError && tgErr.Code == 429:
+		// Лимит превышен — ждём столько, сколько просит Telegram, и откладываем все сообщения в этот чат
+		if j.limited+1 >= maxRateLimited {
+			log.Printf("sender: giving up on chat %d after %d rate limits: %v\n", j.chatID, maxRateLimited, err)
+			finish(j, tgbotapi.Message{}, err)
+			return
+		}
+		delay := time.Duration(tgErr.RetryAfter) * time.Second
+		if delay <= 0 {
+			delay = retryBaseDelay
+		}
+		log.Printf("sender: rate limited for chat %d, retry after %v\n", j.chatID, delay)
+		backOff(j, delay)
+	case isAPIError && tgErr.Code >= 400 && tgErr.Code < 500:
+		// 400/403: чат не найден, бот заблокирован и т.п. — повторять бессмысленно
+		log.Printf("sender: permanent error for chat %d: %v\n", j.chatID, err)
+		if dbErr := db.RecordDeliveryFailure(j.chatID, tgErr.Code, tgErr.Message); dbErr != nil {
+			log.Println(dbErr)
+		}
+		// 403 в личном чате: бот заблокирован или аккаунт удалён
+		if tgErr.Code == 403 && j.chatID > 0 {
+			if dbErr := db.MarkUserBlocked(j.chatID); dbErr != nil {
+				log.Println(dbErr)
+			}
+		}
+		finish(j, tgbotapi.Message{}, err)
+	default:
+		// Сетевые ошибки и 5xx считаем временными
+		if j.attempt+1 >= maxAttempts {
+			log.Printf("sender: giving up on chat %d after %d attempts: %v\n", j.chatID, maxAttempts, err)
+			finish(j, tgbotapi.Message{}, err)
+			return
+		}
+		log.Printf("sender: transient error for chat %d (attempt %d): %v\n", j.chatID, j.attempt+1, err)
+		retry(j, retryBaseDelay<<j.attempt)
+	}
+}
+
+func retry(j *job, delay time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	j.attempt++
+	readyAt := time.Now().Add(delay)
+	if next := nextSlot[j.chatID]; next.Before(readyAt) {
+		nextSlot[j.chatID] = readyAt
+	}
+	j.readyAt = readyAt
+	push(j)
+}
+
+// Отложить j и все сообщения в тот же чат, которые уже стоят в очереди: иначе они
+// уйдут раньше, чем истечёт retry_after, и снова получат 429. Порядок сообщений
+// и интервал между ними сохраняются
+func backOff(j *job, delay time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	j.limited++
+	var pending jobQueue
+	for _, q := range queue {
+		if q.chatID == j.chatID {
+			pending = append(pending, q)
+		}
+	}
+	sort.Sort(pending)
+
+	nextSlot[j.chatID] = time.Now().Add(delay)
+	j.readyAt = reserveSlot(j.chatID, time.Now())
+	for _, q := range pending {
+		q.readyAt = reserveSlot(j.chatID, time.Now())
+	}
+	heap.Init(&queue)
+	push(j)
+}
+
+func finish(j *job, sent tgbotapi.Message, err error) {
+	if j.onDone != nil {
+		j.onDone(sent, err)
+	}
+}
@@ -0,0 +1,60 @@
+package sender
+
+import (
+	"container/heap"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestBackOffPostponesWholeChat(t *testing.T) {
+	// Без Init воркер не запущен, очередь разбирается вручную
+	const chat, other = 1001, 2002
+	for i := 0; i < 3; i++ {
+		Send(chat, tgbotapi.NewMessage(chat, "a"))
+	}
+	Send(other, tgbotapi.NewMessage(other, "b"))
+
+	mu.Lock()
+	first := heap.Pop(&queue).(*job)
+	mu.Unlock()
+	if first.chatID != chat {
+		t.Fatalf("first job is for chat %d", first.chatID)
+	}
+
+	start := time.Now()
+	backOff(first, 30*time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if first.limited != 1 || first.attempt != 0 {
+		t.Errorf("limited=%d attempt=%d, want 1 and 0", first.limited, first.attempt)
+	}
+
+	var last time.Time
+	var postponed int
+	for len(queue) > 0 {
+		j := heap.Pop(&queue).(*job)
+		if j.chatID == other {
+			if j.readyAt.After(start.Add(time.Second)) {
+				t.Errorf("other chat was postponed to %v", j.readyAt)
+			}
+			continue
+		}
+		if j.readyAt.Before(start.Add(30 * time.Second)) {
+			t.Errorf("job %d is ready at %v, before retry_after", j.seq, j.readyAt)
+		}
+		if postponed == 0 && j != first {
+			t.Error("the rate-limited job is no longer first in its chat")
+		}
+		if postponed > 0 && j.readyAt.Sub(last) < privateInterval {
+			t.Errorf("jobs are %v apart, want at least %v", j.readyAt.Sub(last), privateInterval)
+		}
+		last = j.readyAt
+		postponed++
+	}
+	if postponed != 3 {
+		t.Errorf("%d jobs for the chat, want 3", postponed)
+	}
+}
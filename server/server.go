@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// HTTP-сервер бота: принимает вебхуки Telegram, а в будущем — health- и админ-эндпоинты
+type Server struct {
+	mux      *http.ServeMux
+	http     *http.Server
+	certFile string
+	keyFile  string
+}
+
+func New(addr string) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		mux: mux,
+		http: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      30 * time.Second,
+		},
+	}
+}
+
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// Обслуживать HTTPS напрямую. Без этого сервер работает по HTTP, а TLS завершается на reverse proxy
+func (s *Server) WithTLS(certFile, keyFile string) {
+	s.certFile = certFile
+	s.keyFile = keyFile
+}
+
+// Запустить сервер в фоне. Ошибка запуска или работы приходит в возвращаемый канал,
+// который закрывается после остановки сервера
+func (s *Server) Start() <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		var err error
+		if s.certFile != "" && s.keyFile != "" {
+			log.Printf("HTTPS-сервер запущен на %s\n", s.http.Addr)
+			err = s.http.ListenAndServeTLS(s.certFile, s.keyFile)
+		} else {
+			log.Printf("HTTP-сервер запущен на %s\n", s.http.Addr)
+			err = s.http.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("HTTP server error: %v", err)
+		}
+	}()
+	return errs
+}
+
+// Остановить сервер, дождавшись завершения обрабатываемых запросов
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
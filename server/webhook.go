@@ -0,0 +1,74 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+	maxUpdateBytes    = 1 << 20
+)
+
+// Обработчик вебхука: проверяет секретный заголовок Telegram и передаёт обновление в канал updates
+func WebhookHandler(secret string, updates chan<- tgbotapi.Update) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		token := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			log.Printf("Webhook: rejected request from %s: invalid secret token\n", r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		var update tgbotapi.Update
+		body := http.MaxBytesReader(w, r.Body, maxUpdateBytes)
+		if err := json.NewDecoder(body).Decode(&update); err != nil {
+			log.Println("Webhook: unable to decode update:", err)
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case updates <- update:
+			w.WriteHeader(http.StatusOK)
+		case <-r.Context().Done():
+			// Telegram повторит доставку, если не получит 200
+			http.Error(w, "timeout", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// Зарегистрировать вебхук в Telegram. В библиотеке нет поддержки secret_token, поэтому запрос собирается вручную
+func SetWebhook(botAPI *tgbotapi.BotAPI, url, secret string) error {
+	params := make(tgbotapi.Params)
+	params["url"] = url
+	params.AddNonEmpty("secret_token", secret)
+	params.AddBool("drop_pending_updates", false)
+
+	resp, err := botAPI.MakeRequest("setWebhook", params)
+	if err != nil {
+		return fmt.Errorf("setWebhook error: %v", err)
+	}
+	if !resp.Ok {
+		return fmt.Errorf("setWebhook error: %s", resp.Description)
+	}
+	return nil
+}
+
+func DeleteWebhook(botAPI *tgbotapi.BotAPI) error {
+	_, err := botAPI.Request(tgbotapi.DeleteWebhookConfig{})
+	if err != nil {
+		return fmt.Errorf("deleteWebhook error: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const testSecret = "webhook-secret"
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func post(t *testing.T, h http.Handler, method, secret string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, "/telegram", bytes.NewReader(body))
+	if secret != "" {
+		req.Header.Set(secretTokenHeader, secret)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookDispatchesUpdates(t *testing.T) {
+	updates := make(chan tgbotapi.Update, 1)
+	h := WebhookHandler(testSecret, updates)
+
+	rec := post(t, h, http.MethodPost, testSecret, fixture(t, "message.json"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("message: status %d, want 200", rec.Code)
+	}
+	u := <-updates
+	if u.UpdateID != 100001 || u.Message == nil || u.Message.Text != "/start" || u.Message.From.ID != 5001 {
+		t.Errorf("message: got %+v", u)
+	}
+
+	rec = post(t, h, http.MethodPost, testSecret, fixture(t, "callback_query.json"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("callback: status %d, want 200", rec.Code)
+	}
+	u = <-updates
+	if u.CallbackQuery == nil || u.CallbackQuery.Data != "1:reg:42:t0a1b2:abcdef" || u.CallbackQuery.Message.MessageID != 18 {
+		t.Errorf("callback: got %+v", u)
+	}
+}
+
+func TestWebhookRejectsRequests(t *testing.T) {
+	body := fixture(t, "message.json")
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		secret string
+		body   []byte
+		want   int
+	}{
+		{"no secret", http.MethodPost, "", body, http.StatusForbidden},
+		{"wrong secret", http.MethodPost, "other-secret", body, http.StatusForbidden},
+		{"secret prefix", http.MethodPost, testSecret[:4], body, http.StatusForbidden},
+		{"GET", http.MethodGet, testSecret, nil, http.StatusMethodNotAllowed},
+		{"PUT", http.MethodPut, testSecret, body, http.StatusMethodNotAllowed},
+		{"malformed JSON", http.MethodPost, testSecret, []byte(`{"update_id":`), http.StatusBadRequest},
+		{"too large", http.MethodPost, testSecret, bytes.Repeat([]byte(" "), maxUpdateBytes+1), http.StatusBadRequest},
+	} {
+		updates := make(chan tgbotapi.Update, 1)
+		rec := post(t, WebhookHandler(testSecret, updates), tc.method, tc.secret, tc.body)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+		if len(updates) != 0 {
+			t.Errorf("%s: update was dispatched", tc.name)
+		}
+	}
+}
+
+func TestStartReportsListenError(t *testing.T) {
+	srv := New("127.0.0.1:-1")
+	if err := <-srv.Start(); err == nil {
+		t.Fatal("expected an error for an invalid address")
+	}
+}
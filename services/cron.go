@@ -1,10 +1,14 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"laverdad-bot/db"
 	googleapi "laverdad-bot/google-api"
 	"laverdad-bot/locales"
+	"laverdad-bot/render"
+	"laverdad-bot/sender"
 	"log"
 	"time"
 
@@ -15,16 +19,22 @@ import (
 var c *cron.Cron
 var chatID = int64(-4863046517)
 
+// Кнопки записи под анонсом недели. Задаётся пакетом bot, где создаются подписанные callback-кнопки
+var AnnouncementMarkup func(events []db.Event) tgbotapi.InlineKeyboardMarkup
+
 func InitCron(botAPI *tgbotapi.BotAPI) {
-	c = cron.New()
+	// Расписание задаётся по часам клуба, а не сервера
+	c = cron.New(cron.WithLocation(locales.ClubLocation))
 
 	// Creating new weekly event for club games
 	_, err := c.AddFunc("0 0 * * 1", func() {
 		log.Println("Creating new weekly event for club games!")
 
-		CreateFridayEvent()
-		CreateSaturdayEvent()
-		CreateSundayEvent()
+		for _, create := range []func() (bool, error){CreateFridayEvent, CreateSaturdayEvent, CreateSundayEvent} {
+			if _, err := create(); err != nil {
+				log.Println(err)
+			}
+		}
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -39,67 +49,242 @@ func InitCron(botAPI *tgbotapi.BotAPI) {
 		log.Fatal(err)
 	}
 
+	// Личные сообщения о новых играх — отдельно от анонса, который администратор может повторить
+	_, err = c.AddFunc("0 12 * * 1", func() {
+		log.Println("Send new week DMs!")
+		NotifyNewWeekSubscribers()
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Remind members about expiring passes
+	_, err = c.AddFunc("0 11 * * *", func() {
+		log.Println("Send Notifications about expiring memberships!")
+		NotifyExpiringMemberships(botAPI)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	c.Start()
 }
 
-func createNewEvent(starts_at time.Time, location string) {
+// Остановить планировщик и дождаться завершения запущенных задач, но не дольше, чем позволяет ctx
+func StopCron(ctx context.Context) {
+	if c == nil {
+		return
+	}
+	select {
+	case <-c.Stop().Done():
+		log.Println("Cron stopped")
+	case <-ctx.Done():
+		log.Println("Cron stop timeout:", ctx.Err())
+	}
+}
+
+// Создать событие серии. false без ошибки — событие на это время уже есть
+func createNewEvent(starts_at time.Time, location string, series string) (bool, error) {
 	title := "Вечер клубных игр"
-	description := fmt.Sprintf(`Клубные игры (фанки). 4-5 игр по спортивной мафии в дружественной атмосфере.
+	event := db.Event{Title: title, Location: location, StartsAt: starts_at, Series: series}
+	// Описание на каждом языке, основное — на языке клуба
+	event.Descriptions = map[locales.Lang]string{}
+	for _, lang := range locales.Langs {
+		event.Descriptions[lang] = render.EventDescription.Render(lang, render.EventData{Event: render.Event(event, lang)})
+	}
+	event.Description = event.Descriptions[locales.DefaultLang]
+	id, err := db.CreateEvent(event)
+	if errors.Is(err, db.ErrEventExists) {
+		log.Printf("Event %s %s already exists, skipping\n", series, starts_at.Format("2006-01-02 15:04"))
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("Error Creating New Event: %v", err)
+	}
+	event.ID = id
 
-🗓️ %s
-⏳ %s - 23:00 
-📌 %s
-💶 Донат на развитие клуба - 5€ с человека.
+	// Подписчиков записываем сразу, до анонса в группе. Строки в таблицу добавляются
+	// в той же фоновой задаче, что и лист, иначе запись может опередить создание листа
+	lines := registerSubscribers(event)
+	sheetName := googleapi.SheetName(event.Title, event.StartsAt)
+	googleapi.Async(func() {
+		googleapi.AddNewSheet(sheetName)
+		for _, line := range lines {
+			if err := googleapi.AddRegistrationToSheet(sheetName, line); err != nil {
+				log.Println("AddRegistrationToSheet error:", err)
+			}
+		}
+	})
+	return true, nil
+}
 
-Если вы первый раз - ведущий расскажет правила и поможет влиться, во время игры будет делать небольшие комментарии 🤗
-`, locales.FormatDateRU(starts_at), starts_at.Format("15:04"), location)
-	event := db.Event{Title: title, Description: description, Location: location, StartsAt: starts_at}
-	err := db.CreateEvent(event)
-	if err != nil {
-		log.Printf("Error Creating New Event: %v\n", err)
+// Ближайший после now день недели dayOfWeek по часам клуба; если сегодня этот день — через неделю
+func nextDayOfWeek(now time.Time, dayOfWeek time.Weekday) time.Time {
+	now = locales.ClubTime(now)
+	daysUntil := (int(dayOfWeek) - int(now.Weekday()) + 7) % 7
+	if daysUntil == 0 {
+		daysUntil = 7
 	}
-	go googleapi.AddNewSheet(fmt.Sprintf("%s - %s", event.Title, event.StartsAt.Format("02.01")))
+	return now.AddDate(0, 0, daysUntil)
+}
+
+func nextDayOfWeekWithTime(now time.Time, dayOfWeek time.Weekday, hour int, minute int) time.Time {
+	date := nextDayOfWeek(now, dayOfWeek)
+	// time.Date в поясе клуба сам учитывает переход на летнее время
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, locales.ClubLocation)
 }
 
-func nextDayOfWeek(dayOfWeek time.Weekday) time.Time {
-	now := time.Now()
-	daysUntilSaturday := (int(dayOfWeek) - int(now.Weekday()) + 7) % 7
-	if daysUntilSaturday == 0 {
-		daysUntilSaturday = 7
+const (
+	studioLocation     = "🎙 Студия: <a href=\"https://maps.app.goo.gl/K21A6KPB65FbNbcP8\">Calle Conejito de Málaga, 18</a>"
+	restaurantLocation = "🍕 Ресторан: <a href=\"https://maps.app.goo.gl/nhiYHBUkETyxuYaq9\">La Mafia se sienta a la mesa</a>"
+)
+
+// Регулярная игра из недельного расписания
+type Series struct {
+	Key      string
+	Weekday  time.Weekday
+	Hour     int
+	Minute   int
+	Location string
+	// Сколько мест подписчики могут занять до открытия общей записи
+	Seats int
+}
+
+var (
+	FridaySeries   = Series{Key: "friday", Weekday: time.Friday, Hour: 18, Minute: 30, Location: studioLocation, Seats: 12}
+	SaturdaySeries = Series{Key: "saturday", Weekday: time.Saturday, Hour: 17, Minute: 0, Location: studioLocation, Seats: 12}
+	SundaySeries   = Series{Key: "sunday", Weekday: time.Sunday, Hour: 18, Minute: 30, Location: restaurantLocation, Seats: 12}
+)
+
+// Серии в порядке вывода в /subscriptions
+var WeeklySeries = []Series{FridaySeries, SaturdaySeries, SundaySeries}
+
+func LookupSeries(key string) (Series, bool) {
+	for _, s := range WeeklySeries {
+		if s.Key == key {
+			return s, true
+		}
 	}
-	return now.AddDate(0, 0, daysUntilSaturday)
+	return Series{}, false
 }
 
-func nextDayOfWeekWithTime(dayOfWeek time.Weekday, hour int, minute int) time.Time {
-	date := nextDayOfWeek(dayOfWeek)
-	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, date.Location())
+func createSeriesEvent(s Series) (bool, error) {
+	return createNewEvent(nextDayOfWeekWithTime(locales.Now(), s.Weekday, s.Hour, s.Minute), s.Location, s.Key)
 }
 
-func CreateFridayEvent() {
-	starts_at := nextDayOfWeekWithTime(time.Friday, 18, 30)
-	location := "🎙 Студия: [Calle Conejito de Málaga, 18](https://maps.app.goo.gl/K21A6KPB65FbNbcP8)"
-	createNewEvent(starts_at, location)
+func CreateFridayEvent() (bool, error) {
+	return createSeriesEvent(FridaySeries)
 }
 
-func CreateSaturdayEvent() {
-	starts_at := nextDayOfWeekWithTime(time.Saturday, 17, 0)
-	location := "🎙 Студия: [Calle Conejito de Málaga, 18](https://maps.app.goo.gl/K21A6KPB65FbNbcP8)"
-	createNewEvent(starts_at, location)
+func CreateSaturdayEvent() (bool, error) {
+	return createSeriesEvent(SaturdaySeries)
 }
 
-func CreateSundayEvent() {
-	starts_at := nextDayOfWeekWithTime(time.Sunday, 18, 30)
-	location := "🍕 Ресторан: [La Mafia se sienta a la mesa](https://maps.app.goo.gl/nhiYHBUkETyxuYaq9)"
-	createNewEvent(starts_at, location)
+func CreateSundayEvent() (bool, error) {
+	return createSeriesEvent(SundaySeries)
 }
 
 func NotifyRegistrationStarted(botAPI *tgbotapi.BotAPI) {
-	text := `Мирный привет городу, соберёмся играть в 🔴 мафию ⚫ на этой неделе?
-Обратите внимание, что место и время отличаются по дням.
-Для записи на игры перейдите в бот @mafia_appointment_bot`
+	events := db.GetEvents()
+	msg := render.Message(chatID, AnnouncementText(botAPI, events))
+	eventIDs := make([]int64, len(events))
+	for i, e := range events {
+		eventIDs[i] = int64(e.ID)
+	}
+	if AnnouncementMarkup != nil && len(events) > 0 {
+		msg.ReplyMarkup = AnnouncementMarkup(events)
+	}
+
+	// Запоминаем сообщение, чтобы обновлять в нём число записавшихся
+	sender.EnqueueMessage(chatID, msg, func(sent tgbotapi.Message, err error) {
+		if err != nil {
+			log.Println("notifyRegistrationStarted send error:", err)
+			return
+		}
+		if err := db.SaveGroupAnnouncement(sent.Chat.ID, sent.MessageID, eventIDs); err != nil {
+			log.Println(err)
+		}
+	})
+}
+
+// Текст анонса недели с числом записавшихся на каждую игру
+func AnnouncementText(botAPI *tgbotapi.BotAPI, events []db.Event) string {
+	data := render.AnnouncementData{Bot: botAPI.Self.UserName}
+	for _, e := range events {
+		vars := render.Event(e, locales.DefaultLang)
+		if count, err := db.GetEventParticipantsCount(e.ID); err == nil {
+			vars.Players = count
+		}
+		data.Events = append(data.Events, vars)
+	}
+	return render.Announcement.Render(locales.DefaultLang, data)
+}
+
+// Личное сообщение о новых играх недели тем, кто включил его в /settings.
+// О каждой игре сообщается один раз: игры, о которых уже писали, пропускаются.
+// Игрокам, у которых сейчас тихие часы, сообщение откладывается до их окончания
+func NotifyNewWeekSubscribers() {
+	events, err := db.ClaimNewWeekEvents(db.GetEvents())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if len(events) == 0 {
+		return
+	}
+	texts := map[locales.Lang]string{}
+	for _, lang := range locales.Langs {
+		texts[lang] = newWeekText(events, lang)
+	}
+
+	now := locales.Now()
+	for _, r := range db.GetNewWeekSubscribers() {
+		if db.InQuietHours(r.QuietFrom, r.QuietTo, now) {
+			if err := db.ScheduleUserNotification(r.TelegramID, 0, db.KindNewWeek, db.QuietHoursEnd(r.QuietTo, now)); err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+		text := texts[locales.ParseLang(r.Language)]
+		sender.Send(r.ChatID, render.Message(r.ChatID, text))
+	}
+}
+
+// Текст личного сообщения о новых играх недели; пустой, если игр нет
+func NewWeekText(lang locales.Lang) string {
+	events := db.GetEvents()
+	if len(events) == 0 {
+		return ""
+	}
+	return newWeekText(events, lang)
+}
+
+func newWeekText(events []db.Event, lang locales.Lang) string {
+	text := render.T(lang, "newweek.title")
+	for _, e := range events {
+		text += fmt.Sprintf("\n• %s — %s", render.Escape(e.Title), e.StartsAt.Format("02.01 15:04"))
+	}
+	return text + render.T(lang, "newweek.footer")
+}
+
+func NotifyExpiringMemberships(botAPI *tgbotapi.BotAPI) {
+	for _, m := range db.GetExpiringMemberships(3 * 24 * time.Hour) {
+		lang := locales.ParseLang(m.Language)
+		text := render.T(lang, "membership.expiring", m.Type, locales.FormatDate(lang, locales.ClubTime(m.EndsAt)))
+		if m.GamesIncluded > 0 {
+			text += render.T(lang, "membership.games_used", m.GamesUsed, m.GamesIncluded)
+		}
+		text += render.T(lang, "membership.extend_hint")
 
-	msg := tgbotapi.NewMessage(chatID, text)
-	if _, err := botAPI.Send(msg); err != nil {
-		log.Println("notifyRegistrationStarted botAPI.Send error:", err)
+		m := m
+		sender.Enqueue(m.ChatID, render.Message(m.ChatID, text), func(err error) {
+			if err != nil {
+				log.Printf("NotifyExpiringMemberships send error for user %d: %v\n", m.TelegramID, err)
+				return
+			}
+			if err := db.MarkMembershipReminderSent(m.ID); err != nil {
+				log.Println(err)
+			}
+		})
 	}
 }
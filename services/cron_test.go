@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"laverdad-bot/locales"
+)
+
+func TestNextDayOfWeekWithTimeAcrossDST(t *testing.T) {
+	if err := locales.SetTimezone(locales.DefaultTimezone); err != nil {
+		t.Fatal(err)
+	}
+	club := func(s string) time.Time {
+		v, err := locales.ParseClubTime("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	for _, tc := range []struct {
+		name string
+		now  string
+		day  time.Weekday
+		want string
+		utc  string
+	}{
+		{"before spring DST", "2026-03-23 12:00", time.Friday, "2026-03-27 18:30 +0100", "17:30"},
+		{"spring DST day", "2026-03-26 12:00", time.Sunday, "2026-03-29 18:30 +0200", "16:30"},
+		{"same weekday is next week", "2026-03-29 12:00", time.Sunday, "2026-04-05 18:30 +0200", "16:30"},
+		{"late on the DST night", "2026-03-28 23:30", time.Sunday, "2026-03-29 18:30 +0200", "16:30"},
+		{"autumn DST day", "2026-10-19 09:00", time.Sunday, "2026-10-25 18:30 +0100", "17:30"},
+		{"across autumn DST", "2026-10-24 12:00", time.Friday, "2026-10-30 18:30 +0100", "17:30"},
+		{"before autumn DST", "2026-10-19 09:00", time.Friday, "2026-10-23 18:30 +0200", "16:30"},
+	} {
+		got := nextDayOfWeekWithTime(club(tc.now), tc.day, 18, 30)
+		if s := got.Format("2006-01-02 15:04 -0700"); s != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, s, tc.want)
+		}
+		if s := got.UTC().Format("15:04"); s != tc.utc {
+			t.Errorf("%s: %s UTC, want %s", tc.name, s, tc.utc)
+		}
+	}
+
+	// Время сервера в UTC не должно менять день: 23:30 UTC субботы — уже воскресенье в Мадриде
+	got := nextDayOfWeekWithTime(time.Date(2026, 10, 24, 23, 30, 0, 0, time.UTC), time.Sunday, 18, 30)
+	if s := got.Format("2006-01-02"); s != "2026-11-01" {
+		t.Errorf("UTC now: got %s, want 2026-11-01", s)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"laverdad-bot/db"
+	"laverdad-bot/locales"
+	"laverdad-bot/render"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Кнопки под сообщением подписчику: «не смогу на этой неделе» и пауза подписки. Задаётся пакетом bot
+var SubscriptionMarkup func(eventID int, series string, lang locales.Lang) tgbotapi.InlineKeyboardMarkup
+
+// Записать подписчиков серии на новое событие, пока мест хватает, и запланировать им сообщение об этом.
+// Сообщения уходят через очередь уведомлений, поэтому учитывают тихие часы игрока.
+// Возвращает строки регистраций для таблицы
+func registerSubscribers(event db.Event) []db.RegistrationLine {
+	series, ok := LookupSeries(event.Series)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	var lines []db.RegistrationLine
+	for _, s := range db.GetSeriesSubscribers(series.Key) {
+		count, err := db.GetEventParticipantsCount(event.ID)
+		if err != nil {
+			log.Println(err)
+			return lines
+		}
+		if count >= series.Seats {
+			if err := db.ScheduleUserNotification(s.TelegramID, event.ID, db.KindNoSeat, now); err != nil {
+				log.Println(err)
+			}
+			continue
+		}
+
+		if err := db.RegisterUserToEvent(s.TelegramID, event.ID); err != nil {
+			log.Printf("registerSubscribers error for user %d: %v\n", s.TelegramID, err)
+			continue
+		}
+		line, err := db.GetRegistrationLine(int(s.TelegramID), event.ID)
+		if err == nil {
+			lines = append(lines, line)
+		}
+		if err := db.ScheduleRegistrationNotification(s.TelegramID, event.ID, db.KindSubscribed, now); err != nil {
+			log.Println(err)
+		}
+	}
+	return lines
+}
+
+// Сообщение подписчику из очереди уведомлений: записан ли он на игру серии или мест не осталось.
+// Оно информационное, поэтому приходит без звука
+func SubscriptionMessage(n db.DueNotification, lang locales.Lang) tgbotapi.MessageConfig {
+	date := locales.FormatDate(lang, n.EventStartsAt) + ", " + n.EventStartsAt.Format("15:04")
+	var msg tgbotapi.MessageConfig
+	if n.Kind == db.KindNoSeat {
+		msg = render.Message(n.ChatID, render.T(lang, "subscription.no_seat", n.EventTitle, date))
+	} else {
+		msg = render.Message(n.ChatID, render.T(lang, "subscription.registered", n.EventTitle, date))
+		if SubscriptionMarkup != nil {
+			msg.ReplyMarkup = SubscriptionMarkup(n.EventID, n.EventSeries, lang)
+		}
+	}
+	msg.DisableNotification = true
+	return msg
+}